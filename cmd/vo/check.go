@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hymkor/vo/internal/projs"
+	"github.com/hymkor/vo/internal/solution"
+)
+
+// checkSolution reports the inconsistencies of the solution to `w`
+// and returns the number of them.
+func checkSolution(sln *solution.Solution, w io.Writer) int {
+	count := 0
+	report := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, "%s: %s\n", sln.FilePath(), fmt.Sprintf(format, args...))
+		count++
+	}
+
+	guidToProjects := map[string][]*solution.Project{}
+	for _, p := range sln.Projects {
+		key := strings.ToUpper(p.GUID)
+		guidToProjects[key] = append(guidToProjects[key], p)
+	}
+	for _, p := range sln.Projects {
+		if others := guidToProjects[strings.ToUpper(p.GUID)]; len(others) > 1 && others[0] == p {
+			names := make([]string, 0, len(others))
+			for _, o := range others {
+				names = append(names, o.Path)
+			}
+			report("%s: duplicate project GUID: %s", p.GUID, strings.Join(names, ", "))
+		}
+	}
+	known := func(guid string) bool {
+		_, ok := guidToProjects[strings.ToUpper(guid)]
+		return ok
+	}
+
+	if nested := sln.GlobalSection("NestedProjects"); nested != nil {
+		for _, e := range nested.Entries {
+			if !known(e.Key) {
+				report("NestedProjects: unknown project %s", e.Key)
+			}
+			if parent := sln.FindProject(e.Value); parent == nil {
+				report("NestedProjects: unknown solution folder %s", e.Value)
+			} else if !parent.IsFolder() {
+				report("NestedProjects: %s is not a solution folder", parent.Path)
+			}
+		}
+	}
+	if mapping := sln.GlobalSection("ProjectConfigurationPlatforms"); mapping != nil {
+		for _, e := range mapping.Entries {
+			if guid, _, _ := strings.Cut(e.Key, "."); !known(guid) {
+				report("ProjectConfigurationPlatforms: unknown project %s", guid)
+			}
+		}
+	}
+
+	for _, p := range sln.Projects {
+		if p.IsFolder() {
+			continue
+		}
+		if deps := p.Section("ProjectDependencies"); deps != nil {
+			for _, e := range deps.Entries {
+				if !known(e.Key) {
+					report("%s: depends on unknown project %s", p.Path, e.Key)
+				}
+			}
+		}
+		projPath := sln.ProjectPath(p)
+		if !existsFile(projPath) {
+			report("%s: project file not found", p.Path)
+			continue
+		}
+		for _, config := range sln.Configuration {
+			if pc, ok := p.Configs[config]; !ok || pc.ActiveCfg == "" {
+				report("%s: no mapping for the solution configuration %s", p.Path, config)
+			}
+		}
+		projConfigs, err := projs.ReadProjectConfigurations(projPath)
+		if err != nil {
+			report("%s: %s", p.Path, err)
+			continue
+		}
+		if len(projConfigs) <= 0 {
+			continue
+		}
+		for _, config := range sln.Configuration {
+			pc, ok := p.Configs[config]
+			if !ok || pc.ActiveCfg == "" {
+				continue
+			}
+			c, pf := pc.Split()
+			found := false
+			for _, pc1 := range projConfigs {
+				if strings.EqualFold(pc1, c+"|"+pf) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				report("%s: %s is mapped to %s, which the project does not have", p.Path, config, pc.ActiveCfg)
+			}
+		}
+	}
+	return count
+}
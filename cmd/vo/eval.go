@@ -4,18 +4,17 @@ import (
 	"fmt"
 	"io/ioutil"
 
+	"github.com/hymkor/go-sortedkeys"
+
 	"github.com/hymkor/vo/internal/solution"
 )
 
-func eval(sln *solution.Solution, devenvPath, varname string) error {
-	projToConfigToProps, err := getProjToConfigToProps(sln, devenvPath, ioutil.Discard)
-	if err != nil {
-		return err
-	}
-	for proj, configToProps := range projToConfigToProps {
-		fmt.Printf("%s: \n", proj)
-		for config, Props := range configToProps {
-			fmt.Printf("  %s: %s\n", config, Props[varname])
+func eval(sln *solution.Solution, devenvPath, varname string, overrides map[string]string) error {
+	projToConfigToProject := getProjToConfigToProject(sln, devenvPath, overrides, ioutil.Discard)
+	for pair1 := sortedkeys.New(projToConfigToProject); pair1.Range(); {
+		fmt.Printf("%s: \n", pair1.Key)
+		for pair2 := sortedkeys.New(pair1.Value); pair2.Range(); {
+			fmt.Printf("  %s: %s\n", pair2.Key, pair2.Value.Properties.Get(varname))
 		}
 	}
 	return nil
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hymkor/vo/internal/depgraph"
+)
+
+func nodeIDs(g *depgraph.Graph) map[*depgraph.Node]string {
+	ids := make(map[*depgraph.Node]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+func writeDot(g *depgraph.Graph, name string, w io.Writer) {
+	ids := nodeIDs(g)
+	fmt.Fprintf(w, "digraph %q {\n", name)
+	for _, n := range g.Nodes {
+		attr := ""
+		if n.External {
+			attr = ", style=dashed"
+		}
+		fmt.Fprintf(w, "  %s [label=%q%s];\n", ids[n], n.Name, attr)
+	}
+	for _, e := range g.Edges {
+		attr := ""
+		if e.Kind == depgraph.KindSolution {
+			attr = " [style=dashed]"
+		}
+		fmt.Fprintf(w, "  %s -> %s%s;\n", ids[e.From], ids[e.To], attr)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeMermaid(g *depgraph.Graph, w io.Writer) {
+	ids := nodeIDs(g)
+	fmt.Fprintln(w, "graph LR")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[n], strings.ReplaceAll(n.Name, `"`, "#quot;"))
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Kind == depgraph.KindSolution {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(w, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+}
+
+type jsonNode struct {
+	Name         string   `json:"name"`
+	Path         string   `json:"path"`
+	GUID         string   `json:"guid,omitempty"`
+	External     bool     `json:"external,omitempty"`
+	Dependencies []string `json:"dependencies"`
+}
+
+type jsonGraph struct {
+	Solution   string     `json:"solution"`
+	Projects   []jsonNode `json:"projects"`
+	BuildOrder []string   `json:"buildOrder"`
+	Cycles     [][]string `json:"cycles"`
+}
+
+func nodePaths(nodes []*depgraph.Node) []string {
+	result := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, n.Path)
+	}
+	return result
+}
+
+func writeJSON(g *depgraph.Graph, name string, w io.Writer) error {
+	doc := jsonGraph{
+		Solution: name,
+		Projects: []jsonNode{},
+		Cycles:   [][]string{},
+	}
+	for _, n := range g.Nodes {
+		doc.Projects = append(doc.Projects, jsonNode{
+			Name:         n.Name,
+			Path:         n.Path,
+			GUID:         n.GUID,
+			External:     n.External,
+			Dependencies: nodePaths(g.DependsOn(n)),
+		})
+	}
+	order, _ := g.BuildOrder()
+	doc.BuildOrder = nodePaths(order)
+	for _, c := range g.Cycles() {
+		doc.Cycles = append(doc.Cycles, nodePaths(c))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&doc)
+}
+
+func reportCycles(g *depgraph.Graph, w io.Writer) bool {
+	cycles := g.Cycles()
+	for _, c := range cycles {
+		var buffer strings.Builder
+		for _, n := range c {
+			buffer.WriteString(n.Name)
+			buffer.WriteString(" -> ")
+		}
+		buffer.WriteString(c[0].Name)
+		fmt.Fprintf(w, "cycle: %s\n", buffer.String())
+	}
+	return len(cycles) > 0
+}
+
+// showGraph prints the dependency graph of the projects in `format`
+// (dot, mermaid, json or order).
+func showGraph(g *depgraph.Graph, name, format string, w, warning io.Writer) error {
+	hasCycle := reportCycles(g, warning)
+	switch strings.ToLower(format) {
+	case "", "dot":
+		writeDot(g, name, w)
+	case "mermaid":
+		writeMermaid(g, w)
+	case "json":
+		if err := writeJSON(g, name, w); err != nil {
+			return err
+		}
+	case "order":
+		order, err := g.BuildOrder()
+		for _, n := range order {
+			fmt.Fprintln(w, n.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("%s: unknown format", format)
+	}
+	if hasCycle {
+		return fmt.Errorf("%s: %w", name, depgraph.ErrCycle)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hymkor/go-sortedkeys"
+
+	"github.com/hymkor/vo/internal/projs"
+	"github.com/hymkor/vo/internal/solution"
+)
+
+func writeImport(node *projs.Import, indent string, w io.Writer) {
+	name := node.Path
+	if name == "" {
+		name = node.Project
+	}
+	switch node.Status {
+	case projs.ImportLoaded:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+	case projs.ImportSkipped:
+		fmt.Fprintf(w, "%s%s [skipped: %s]\n", indent, name, strings.TrimSpace(node.Condition))
+	default:
+		fmt.Fprintf(w, "%s%s [%s]\n", indent, name, node.Status)
+	}
+	for _, child := range node.Imports {
+		writeImport(child, indent+"  ", w)
+	}
+}
+
+// showImports prints the import tree of each project and configuration.
+func showImports(sln *solution.Solution, devenvPath, config string, overrides map[string]string, w, warning io.Writer) error {
+	projToConfigToProject := getProjToConfigToProject(sln, devenvPath, overrides, warning)
+	for pair1 := sortedkeys.New(projToConfigToProject); pair1.Range(); {
+		fmt.Fprintf(w, "%s:\n", pair1.Key)
+		for pair2 := sortedkeys.New(pair1.Value); pair2.Range(); {
+			if config != "" && !strings.EqualFold(config, pair2.Key) {
+				continue
+			}
+			fmt.Fprintf(w, "  %s:\n", pair2.Key)
+			if imports := pair2.Value.Imports; imports != nil {
+				writeImport(imports, "    ", w)
+			}
+		}
+	}
+	return nil
+}
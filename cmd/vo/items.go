@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hymkor/go-sortedkeys"
+
+	"github.com/hymkor/vo/internal/projs"
+	"github.com/hymkor/vo/internal/solution"
+)
+
+func writeItem(item *projs.Item, withMetadata bool, w io.Writer) {
+	fmt.Fprintf(w, "    %s: %s\n", item.Type, item.Include)
+	if !withMetadata {
+		return
+	}
+	for _, name := range item.MetadataNames() {
+		fmt.Fprintf(w, "      %s=%s\n", name, item.Get(name))
+	}
+}
+
+// showItems prints the items of the projects in the solution.
+// Empty `itemType` means all types and empty `config` means all configurations.
+func showItems(sln *solution.Solution, devenvPath, itemType, config string, withMetadata bool, w, warning io.Writer) error {
+	projToConfigToProject := getProjToConfigToProject(sln, devenvPath, nil, warning)
+	for pair1 := sortedkeys.New(projToConfigToProject); pair1.Range(); {
+		fmt.Fprintf(w, "%s:\n", pair1.Key)
+		for pair2 := sortedkeys.New(pair1.Value); pair2.Range(); {
+			if config != "" && !strings.EqualFold(config, pair2.Key) {
+				continue
+			}
+			fmt.Fprintf(w, "  %s:\n", pair2.Key)
+			project := pair2.Value
+			items := project.Items
+			if itemType != "" {
+				items = project.ItemsOf(itemType)
+			}
+			for _, item := range items {
+				writeItem(item, withMetadata, w)
+			}
+		}
+	}
+	return nil
+}
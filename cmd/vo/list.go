@@ -1,13 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/hymkor/go-sortedkeys"
 
@@ -16,11 +18,6 @@ import (
 	"github.com/hymkor/vo/internal/solution"
 )
 
-const dotNetDLLType = "Library"
-const nativeDLLType = "DynamicLibrary"
-
-var rxCondition = regexp.MustCompile(`^\s*'([^']*)'\s*==\s*'([^']*)'`)
-
 func getVCTargetsPath(compath string) (string, error) {
 	vcpath := filepath.Join(filepath.Dir(compath), `..\..\MSBuild\Microsoft\VC`)
 	files, err := ioutil.ReadDir(vcpath)
@@ -41,95 +38,281 @@ func withoutExt(fname string) string {
 	return fname[:len(fname)-len(filepath.Ext(fname))]
 }
 
-func getProjToConfigToProps(sln *solution.Solution, devenvPath string, warning io.Writer) (map[string]map[string]projs.Properties, error) {
-	var vcTargetsPath string
-	if devenvPath != "" {
-		vcTargetsPath, _ = getVCTargetsPath(devenvPath)
+func withSeparator(dir string) string {
+	if strings.HasSuffix(dir, string(filepath.Separator)) {
+		return dir
 	}
+	return dir + string(filepath.Separator)
+}
 
-	projToConfigToProps := map[string]map[string]projs.Properties{}
+type evalJob struct {
+	proj          *solution.Project
+	projPath      string
+	configuration string
+	framework     string // TargetFramework of the inner build or ""
+	props         projs.Properties
+	globals       projs.Properties
+	project       *projs.Project
+	err           error
+	log           bytes.Buffer
+	inner         []*evalJob // the evaluations for each TargetFramework
+}
+
+// runWorkers calls `do(0)` ... `do(n-1)` with at most `workers` goroutines.
+func runWorkers(n, workers int, do func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// runJobs evaluates the projects of `jobs` concurrently.
+func runJobs(jobs []*evalJob, warning io.Writer) {
+	runWorkers(len(jobs), runtime.NumCPU(), func(i int) {
+		job := jobs[i]
+		var log io.Writer = ioutil.Discard
+		if warning != ioutil.Discard {
+			log = &job.log
+		}
+		job.project, job.err = loadProject(job.projPath, job.props, job.globals, log)
+	})
+}
+
+// done writes the log of the job to `warning` and reports its error to stderr.
+// It returns false when the project failed to be evaluated.
+func (job *evalJob) done(warning io.Writer) bool {
+	warning.Write(job.log.Bytes())
+	if job.err == nil {
+		return true
+	}
+	if job.framework != "" {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s: %s\n", job.projPath, job.configuration, job.framework, job.err)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n", job.projPath, job.configuration, job.err)
+	}
+	return false
+}
 
-	for _projPath := range sln.Project {
-		projPath := filepath.Join(filepath.Dir(sln.Path), _projPath)
-		configToProps := map[string]projs.Properties{}
+// forEachProjectConfig evaluates each project in each configuration of the solution
+// and calls `f` with the result. `overrides` are given by -p Name=Value and
+// win over the project files. When `byFramework` is true, the projects with
+// TargetFrameworks are evaluated again for each framework and `f` is called
+// for each of them, otherwise `framework` is always "".
+// The evaluations run concurrently, but `f` is called in the order of
+// the projects and the configurations in the solution.
+// The projects failing to be evaluated are reported to stderr and skipped.
+func forEachProjectConfig(sln *solution.Solution, devenvPath string, overrides map[string]string, byFramework bool, warning io.Writer,
+	f func(proj *solution.Project, configuration, framework string, project *projs.Project)) {
+
+	var vcTargetsPath, extensionsPath string
+	if devenvPath != "" {
+		vcTargetsPath, _ = getVCTargetsPath(devenvPath)
+		extensionsPath = filepath.Join(filepath.Dir(devenvPath), `..\..\MSBuild`)
+	}
+	slnPath, err := filepath.Abs(sln.FilePath())
+	if err != nil {
+		slnPath = sln.FilePath()
+	}
+	var jobs []*evalJob
+	for _, proj := range sln.Projects {
+		if proj.IsFolder() {
+			continue
+		}
+		projPath := sln.ProjectPath(proj)
 		for _, configuration := range sln.Configuration {
-			piece := strings.Split(configuration, "|")
-			props := projs.Properties{
-				"Configuration": strings.ReplaceAll(strings.TrimSpace(piece[0]), " ", ""),
-				"Platform":      strings.ReplaceAll(strings.TrimSpace(piece[1]), " ", ""),
+			var projConfig, projPlatform string
+			if pc, ok := proj.Configs[configuration]; ok && pc.ActiveCfg != "" {
+				projConfig, projPlatform = pc.Split()
+			} else {
+				projConfig, projPlatform, _ = strings.Cut(configuration, "|")
+				projConfig = strings.ReplaceAll(strings.TrimSpace(projConfig), " ", "")
+				projPlatform = strings.ReplaceAll(strings.TrimSpace(projPlatform), " ", "")
+			}
+			props := projs.NewProperties(map[string]string{
 				"VCTargetsPath": vcTargetsPath,
 				"ProjectName":   withoutExt(filepath.Base(projPath)),
-				"ProjectDir":    filepath.Dir(projPath),
+				"ProjectDir":    withSeparator(filepath.Dir(projPath)),
+			})
+			// the properties given by the solution build are global as MSBuild does
+			globals := projs.NewProperties(map[string]string{
+				"Configuration":    projConfig,
+				"Platform":         projPlatform,
+				"SolutionDir":      withSeparator(filepath.Dir(slnPath)),
+				"SolutionPath":     slnPath,
+				"SolutionFileName": filepath.Base(slnPath),
+				"SolutionName":     withoutExt(filepath.Base(slnPath)),
+				"SolutionExt":      filepath.Ext(slnPath),
+			})
+			if extensionsPath != "" {
+				props.Set("MSBuildExtensionsPath", extensionsPath)
+				props.Set("MSBuildExtensionsPath32", extensionsPath)
+				props.Set("MSBuildExtensionsPath64", extensionsPath)
 			}
-			err := props.LoadProject(projPath, warning)
-			if err != nil {
+			for name, value := range overrides {
+				globals.Set(name, value)
+			}
+			jobs = append(jobs, &evalJob{
+				proj:          proj,
+				projPath:      projPath,
+				configuration: configuration,
+				props:         props,
+				globals:       globals,
+			})
+		}
+	}
+	runJobs(jobs, warning)
+
+	if byFramework {
+		var innerJobs []*evalJob
+		for _, job := range jobs {
+			if job.err != nil {
 				continue
-				// return nil, err
 			}
-			configToProps[configuration] = props
+			for _, framework := range job.project.TargetFrameworks() {
+				globals := job.globals.Clone()
+				globals.Set("TargetFramework", framework)
+				inner := &evalJob{
+					proj:          job.proj,
+					projPath:      job.projPath,
+					configuration: job.configuration,
+					framework:     framework,
+					props:         job.props,
+					globals:       globals,
+				}
+				job.inner = append(job.inner, inner)
+				innerJobs = append(innerJobs, inner)
+			}
 		}
-		if len(configToProps) > 0 {
-			projToConfigToProps[_projPath] = configToProps
+		runJobs(innerJobs, warning)
+	}
+
+	for _, job := range jobs {
+		if !job.done(warning) {
+			continue
+		}
+		if len(job.inner) <= 0 {
+			f(job.proj, job.configuration, "", job.project)
+			continue
+		}
+		for _, inner := range job.inner {
+			if inner.done(warning) {
+				f(inner.proj, inner.configuration, inner.framework, inner.project)
+			}
 		}
 	}
-	return projToConfigToProps, nil
 }
 
-func listupProduct(sln *solution.Solution, devenvPath string, warning io.Writer) (map[string]map[string]string, error) {
-	projToConfigToProp, err := getProjToConfigToProps(sln, devenvPath, warning)
-	if err != nil {
+// loadProject evaluates the project with copies of `props` and `globals`.
+func loadProject(projPath string, props, globals projs.Properties, warning io.Writer) (*projs.Project, error) {
+	project := projs.NewProject(props.Clone())
+	project.GlobalProperties = globals.Clone()
+	project.SdksPath = globalSdksPath
+	if err := project.Load(projPath, warning); err != nil {
 		return nil, err
 	}
-	projToConfigToProduct := map[string]map[string]string{}
-	for proj, configToProps := range projToConfigToProp {
-		configToProduct := map[string]string{}
-		for config, props := range configToProps {
-			outputFile := props["OutputFile"]
-			if outputFile == "" {
-				filename := props["AssemblyName"]
-				if filename == "" {
-					filename = props["ProjectName"]
-				}
-				if ext, ok := props["TargetExt"]; ok {
-					filename += ext
-				} else if props["OutputType"] == dotNetDLLType {
-					filename += ".dll"
-				} else if props["ConfigurationType"] == nativeDLLType {
-					filename += ".dll"
-				} else {
-					filename += ".exe"
-				}
-				outdir := props["OutputPath"]
-				if outdir == "" {
-					outdir = props["OutDir"]
-				}
-				outputFile = filepath.Join(outdir, filename)
+	return project, nil
+}
+
+func getProjToConfigToProject(sln *solution.Solution, devenvPath string, overrides map[string]string, warning io.Writer) map[string]map[string]*projs.Project {
+	projToConfigToProject := map[string]map[string]*projs.Project{}
+	forEachProjectConfig(sln, devenvPath, overrides, false, warning,
+		func(proj *solution.Project, configuration, _ string, project *projs.Project) {
+			configToProject, ok := projToConfigToProject[proj.Path]
+			if !ok {
+				configToProject = map[string]*projs.Project{}
+				projToConfigToProject[proj.Path] = configToProject
+			}
+			configToProject[configuration] = project
+		})
+	return projToConfigToProject
+}
+
+// getProjToConfigToFrameworkToProject is same as getProjToConfigToProject,
+// but the projects with TargetFrameworks are evaluated for each framework.
+// The projects without them are stored with the framework "".
+func getProjToConfigToFrameworkToProject(sln *solution.Solution, devenvPath string, overrides map[string]string, warning io.Writer) map[string]map[string]map[string]*projs.Project {
+	result := map[string]map[string]map[string]*projs.Project{}
+	forEachProjectConfig(sln, devenvPath, overrides, true, warning,
+		func(proj *solution.Project, configuration, framework string, project *projs.Project) {
+			configToFrameworkToProject, ok := result[proj.Path]
+			if !ok {
+				configToFrameworkToProject = map[string]map[string]*projs.Project{}
+				result[proj.Path] = configToFrameworkToProject
+			}
+			frameworkToProject, ok := configToFrameworkToProject[configuration]
+			if !ok {
+				frameworkToProject = map[string]*projs.Project{}
+				configToFrameworkToProject[configuration] = frameworkToProject
+			}
+			frameworkToProject[framework] = project
+		})
+	return result
+}
+
+// listupProduct returns the paths of the executables built by the solution
+// as project -> configuration -> target framework -> path.
+// The framework is "" for the projects without TargetFrameworks.
+// When `all` is false, the projects not built in the configuration are excluded.
+func listupProduct(sln *solution.Solution, devenvPath string, all bool, overrides map[string]string, warning io.Writer) map[string]map[string]map[string]string {
+	projToConfigToFrameworkToProject := getProjToConfigToFrameworkToProject(sln, devenvPath, overrides, warning)
+	projToConfigToProduct := map[string]map[string]map[string]string{}
+	for _, proj := range sln.Projects {
+		configToFrameworkToProject, ok := projToConfigToFrameworkToProject[proj.Path]
+		if !ok {
+			continue
+		}
+		configToProduct := map[string]map[string]string{}
+		for config, frameworkToProject := range configToFrameworkToProject {
+			if !all && !sln.IsBuilt(proj, config) {
+				fmt.Fprintf(warning, "%s: not built in %s\n", proj.Path, config)
+				continue
+			}
+			frameworkToProduct := map[string]string{}
+			for framework, project := range frameworkToProject {
+				frameworkToProduct[framework] = project.OutputFile()
 			}
-			target := filepath.Join(props["ProjectDir"], outputFile)
-			configToProduct[config] = target
+			configToProduct[config] = frameworkToProduct
+		}
+		if len(configToProduct) > 0 {
+			projToConfigToProduct[proj.Path] = configToProduct
 		}
-		projToConfigToProduct[proj] = configToProduct
 	}
-	return projToConfigToProduct, nil
+	return projToConfigToProduct
 }
 
-func listProductInline(sln *solution.Solution, devenvPath string, warning io.Writer) error {
-	projToConfigToProduct, err := listupProduct(sln, devenvPath, warning)
-	if err != nil {
-		return err
-	}
+func listProductInline(sln *solution.Solution, devenvPath string, all bool, overrides map[string]string, warning io.Writer) error {
+	projToConfigToProduct := listupProduct(sln, devenvPath, all, overrides, warning)
 	uniq := make(map[string]struct{})
 	ofs := ""
-	for _, configToProduct := range projToConfigToProduct {
-		for _, s := range configToProduct {
-			if _, ok := uniq[s]; !ok {
-				if strings.ContainsRune(s, ' ') {
-					fmt.Printf(`%s"%s"`, ofs, s)
-				} else {
-					fmt.Print(ofs, s)
+	for _, proj := range sln.Projects {
+		configToProduct, ok := projToConfigToProduct[proj.Path]
+		if !ok {
+			continue
+		}
+		for pair1 := sortedkeys.New(configToProduct); pair1.Range(); {
+			for pair2 := sortedkeys.New(pair1.Value); pair2.Range(); {
+				s := pair2.Value
+				if _, ok := uniq[s]; !ok {
+					if strings.ContainsRune(s, ' ') {
+						fmt.Printf(`%s"%s"`, ofs, s)
+					} else {
+						fmt.Print(ofs, s)
+					}
+					ofs = " "
+					uniq[s] = struct{}{}
 				}
-				ofs = " "
-				uniq[s] = struct{}{}
 			}
 		}
 	}
@@ -144,21 +327,27 @@ func showVer(fname string, w io.Writer) {
 	}
 }
 
-func solutionsToAllProjects(slns []*TargetSolution, warning io.Writer) map[string]map[string]string {
-	projs := make(map[string]map[string]string)
+func solutionsToAllProjects(slns []*TargetSolution, all bool, overrides map[string]string, warning io.Writer) map[string]map[string]map[string]string {
+	projs := make(map[string]map[string]map[string]string)
 	for _, sln := range slns {
-		projToConfigToProduct, err := listupProduct(sln.Solution, "", warning)
-		if err != nil {
-			continue
-		}
+		projToConfigToProduct := listupProduct(sln.Solution, "", all, overrides, warning)
 		for proj, configToProduct := range projToConfigToProduct {
-			projs[proj] = configToProduct
+			projs[filepath.Join(filepath.Dir(sln.Path), proj)] = configToProduct
 		}
 	}
 	return projs
 }
 
-func listProductLong(projToConfigToProduct map[string]map[string]string) error {
+func existsProduct(fname string) bool {
+	fd, err := os.Open(fname)
+	if err != nil {
+		return false
+	}
+	fd.Close()
+	return true
+}
+
+func listProductLong(projToConfigToProduct map[string]map[string]map[string]string) error {
 	for pair1 := sortedkeys.New(projToConfigToProduct); pair1.Range(); {
 		proj := pair1.Key
 		configToProduct := pair1.Value
@@ -168,13 +357,27 @@ func listProductLong(projToConfigToProduct map[string]map[string]string) error {
 		fmt.Fprintf(&buffer, "%s:\n", proj)
 		for pair2 := sortedkeys.New(configToProduct); pair2.Range(); {
 			config := pair2.Key
-			fname := pair2.Value
-			if fd, err := os.Open(fname); err == nil {
-				fd.Close()
-				fmt.Print(buffer.String())
-				buffer.Reset()
-				fmt.Printf("  %s:\n    ", config)
-				showVer(fname, os.Stdout)
+			frameworkToProduct := pair2.Value
+			if fname, ok := frameworkToProduct[""]; ok && len(frameworkToProduct) == 1 {
+				if existsProduct(fname) {
+					fmt.Print(buffer.String())
+					buffer.Reset()
+					fmt.Printf("  %s:\n    ", config)
+					showVer(fname, os.Stdout)
+				}
+				continue
+			}
+			configHeader := fmt.Sprintf("  %s:\n", config)
+			for pair3 := sortedkeys.New(frameworkToProduct); pair3.Range(); {
+				framework := pair3.Key
+				fname := pair3.Value
+				if existsProduct(fname) {
+					fmt.Print(buffer.String(), configHeader)
+					buffer.Reset()
+					configHeader = ""
+					fmt.Printf("    %s:\n      ", framework)
+					showVer(fname, os.Stdout)
+				}
 			}
 		}
 	}
@@ -13,6 +13,7 @@ import (
 	_ "github.com/mattn/getwild"
 	"github.com/urfave/cli/v2"
 
+	"github.com/hymkor/vo/internal/depgraph"
 	"github.com/hymkor/vo/internal/solution"
 	"github.com/hymkor/vo/internal/vswhere"
 )
@@ -34,11 +35,38 @@ type TargetSolution struct {
 	DevenvPath string
 }
 
-func seekSolutions(flags *vswhere.Flag, args []string, verbose io.Writer, mustHaveDevenv bool) ([]*TargetSolution, error) {
-	slnPaths, err := solution.Find(args)
-	if err != nil {
-		return nil, err
+// findSolutionFiles returns the solution files given by the arguments
+// or in the current directory. With -R, it returns the solution files
+// under the directories given by the arguments or the current directory.
+func findSolutionFiles(c *cli.Context) ([]string, error) {
+	if !c.Bool("R") {
+		return solution.Find(c.Args().Slice())
+	}
+	ignores := c.StringSlice("ignore")
+	if len(ignores) <= 0 {
+		ignores = solution.DefaultIgnores
+	}
+	roots := []string{}
+	for _, arg := range c.Args().Slice() {
+		if stat, err := os.Stat(arg); err == nil && stat.IsDir() {
+			roots = append(roots, arg)
+		}
+	}
+	if len(roots) <= 0 {
+		roots = append(roots, ".")
+	}
+	result := []string{}
+	for _, root := range roots {
+		slnPaths, err := solution.FindRecursive(root, ignores)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, slnPaths...)
 	}
+	return result, nil
+}
+
+func seekSolutions(flags *vswhere.Flag, slnPaths []string, verbose io.Writer, mustHaveDevenv bool) ([]*TargetSolution, error) {
 	targets := make([]*TargetSolution, 0, len(slnPaths))
 	for _, slnPath := range slnPaths {
 		sln, err := solution.New(slnPath)
@@ -60,7 +88,11 @@ func seekSolutions(flags *vswhere.Flag, args []string, verbose io.Writer, mustHa
 }
 
 func seekOneSolution(flags *vswhere.Flag, args []string, verbose io.Writer) (*TargetSolution, error) {
-	slns, err := seekSolutions(flags, args, verbose, true)
+	slnPaths, err := solution.Find(args)
+	if err != nil {
+		return nil, err
+	}
+	slns, err := seekSolutions(flags, slnPaths, verbose, true)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +113,7 @@ func seekOneSolution(flags *vswhere.Flag, args []string, verbose io.Writer) (*Ta
 }
 
 func seekConfig(c *cli.Context, sln *solution.Solution) []string {
-	if conf := c.String("config"); conf != "" {
+	if conf := c.String("c"); conf != "" {
 		return []string{conf}
 	}
 
@@ -133,16 +165,60 @@ func context2flag(c *cli.Context) *vswhere.Flag {
 }
 
 func build(c *cli.Context, action string) error {
-	sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
+	if !c.Bool("R") {
+		sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
+		if err != nil {
+			return err
+		}
+		return buildSolution(c, sln, action)
+	}
+	slnPaths, err := findSolutionFiles(c)
+	if err != nil {
+		return err
+	}
+	if len(slnPaths) <= 0 {
+		return errors.New("no solution files")
+	}
+	slns, err := seekSolutions(context2flag(c), slnPaths, getVerboseOut(c), true)
+	if err != nil {
+		return err
+	}
+	for _, sln := range slns {
+		if err := buildSolution(c, sln, action); err != nil {
+			return fmt.Errorf("%s: %w", sln.FilePath(), err)
+		}
+	}
+	return nil
+}
+
+func buildSolution(c *cli.Context, sln *TargetSolution, action string) error {
+	properties, err := getProperties(c)
 	if err != nil {
 		return err
 	}
 	confs := seekConfig(c, sln.Solution)
+	if len(properties) > 0 {
+		// devenv.com can not take properties
+		msbuild, err := findMSBuild(sln.DevenvPath)
+		if err != nil {
+			return err
+		}
+		if len(confs) <= 0 {
+			confs = []string{""}
+		}
+		for _, conf1 := range confs {
+			err := run(c.Bool("n"), msbuild, msbuildArgs(sln.FilePath(), action, conf1, properties)...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	if len(confs) <= 0 {
-		return run(c.Bool("n"), sln.DevenvPath, sln.Path, action)
+		return run(c.Bool("n"), sln.DevenvPath, sln.FilePath(), action)
 	}
 	for _, conf1 := range confs {
-		err = run(c.Bool("n"), sln.DevenvPath, sln.Path, action, conf1)
+		err := run(c.Bool("n"), sln.DevenvPath, sln.FilePath(), action, conf1)
 		if err != nil {
 			return err
 		}
@@ -159,9 +235,10 @@ var (
 	globalFlagLatest  = false
 	globalFlagWarning = false
 	globalFlagVerbose = false
+	globalSdksPath    = ""
 )
 
-func mains() error {
+func newApp() *cli.App {
 	globalFlags := []cli.Flag{
 		&cli.BoolFlag{
 			Name:        "2010",
@@ -203,6 +280,12 @@ func mains() error {
 			Usage:       "verbose",
 			Destination: &globalFlagVerbose,
 		},
+		&cli.StringFlag{
+			Name:        "sdk",
+			Usage:       "the directory of SDKs for SDK-style projects (ex. C:\\Program Files\\dotnet\\sdk\\8.0.100\\Sdks)",
+			EnvVars:     []string{"MSBuildSDKsPath"},
+			Destination: &globalSdksPath,
+		},
 	}
 
 	buildOptions := []cli.Flag{
@@ -232,6 +315,28 @@ func mains() error {
 		},
 	}
 
+	recursiveOptions := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "R",
+			Usage: "search solutions under the directories recursively",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ignore",
+			Usage: "patterns of files and directories skipped with -R (default: bin/ obj/ .git/ .vs/)",
+		},
+	}
+	buildOptions = append(buildOptions, recursiveOptions...)
+	buildOptions = append(buildOptions, propertyOption)
+
+	listOptions := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "a",
+			Usage: "show also projects not built in the configuration",
+		},
+	}
+	listOptions = append(listOptions, recursiveOptions...)
+	listOptions = append(listOptions, propertyOption)
+
 	for _, f := range globalFlags {
 		if bf, ok := f.(*cli.BoolFlag); ok {
 			buildOptions = append(buildOptions, &cli.BoolFlag{
@@ -253,9 +358,9 @@ func mains() error {
 					if err != nil {
 						return err
 					}
-					err = run(c.Bool("n"), sln.DevenvPath, sln.Path)
+					err = run(c.Bool("n"), sln.DevenvPath, sln.FilePath())
 					if err != nil {
-						return fmt.Errorf("%s: %w", sln.Path, err)
+						return fmt.Errorf("%s: %w", sln.FilePath(), err)
 					}
 					return nil
 				},
@@ -279,8 +384,17 @@ func mains() error {
 			{
 				Name:  "ls",
 				Usage: "list up expected executables inline",
+				Flags: listOptions,
 				Action: func(c *cli.Context) error {
-					slns, err := seekSolutions(context2flag(c), c.Args().Slice(), getVerboseOut(c), false)
+					slnPaths, err := findSolutionFiles(c)
+					if err != nil {
+						return err
+					}
+					slns, err := seekSolutions(context2flag(c), slnPaths, getVerboseOut(c), false)
+					if err != nil {
+						return err
+					}
+					properties, err := getProperties(c)
 					if err != nil {
 						return err
 					}
@@ -288,9 +402,9 @@ func mains() error {
 						return slns[i].Path < slns[j].Path
 					})
 					for i, sln := range slns {
-						err = listProductInline(sln.Solution, sln.DevenvPath, getWarningOut(c))
+						err = listProductInline(sln.Solution, sln.DevenvPath, c.Bool("a"), properties, getWarningOut(c))
 						if err != nil {
-							fmt.Fprintf(os.Stderr, "%s: %w", sln.Path, err)
+							fmt.Fprintf(os.Stderr, "%s: %s\n", sln.Path, err)
 							continue
 						}
 						if i == len(slns)-1 {
@@ -305,16 +419,154 @@ func mains() error {
 			{
 				Name:  "list",
 				Usage: "list up existing executables and thier version-information with long format",
+				Flags: listOptions,
 				Action: func(c *cli.Context) error {
-					slns, err := seekSolutions(context2flag(c), c.Args().Slice(), getVerboseOut(c), false)
+					slnPaths, err := findSolutionFiles(c)
+					if err != nil {
+						return err
+					}
+					slns, err := seekSolutions(context2flag(c), slnPaths, getVerboseOut(c), false)
 					if err != nil {
 						return err
 					}
-					projs := solutionsToAllProjects(slns, getWarningOut(c))
+					properties, err := getProperties(c)
+					if err != nil {
+						return err
+					}
+					projs := solutionsToAllProjects(slns, c.Bool("a"), properties, getWarningOut(c))
 
 					return listProductLong(projs)
 				},
 			},
+			{
+				Name:  "sln",
+				Usage: "edit the solution file",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "register projects to the solution",
+						ArgsUsage: "[SOLUTION] PROJECT...",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "f",
+								Usage: "the solution folder to put projects in (ex. src/libs)",
+							},
+							&cli.BoolFlag{
+								Name:  "n",
+								Usage: "dry run (print the solution instead of saving)",
+							},
+						},
+						Action: slnAdd,
+					},
+					{
+						Name:      "remove",
+						Usage:     "drop projects from the solution",
+						ArgsUsage: "[SOLUTION] PROJECT...",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "n",
+								Usage: "dry run (print the solution instead of saving)",
+							},
+						},
+						Action: slnRemove,
+					},
+				},
+			},
+			{
+				Name:  "tree",
+				Usage: "show solution folders and projects in them",
+				Action: func(c *cli.Context) error {
+					slnPaths, err := solution.Find(c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					for _, slnPath := range slnPaths {
+						sln, err := solution.New(slnPath)
+						if err != nil {
+							return fmt.Errorf("%s: %w", slnPath, err)
+						}
+						showTree(sln, os.Stdout)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "graph",
+				Usage: "show the dependencies between projects",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "f",
+						Value: "dot",
+						Usage: "output format: dot, mermaid, json or order(build order)",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					slnPaths, err := solution.Find(c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					for _, slnPath := range slnPaths {
+						sln, err := solution.New(slnPath)
+						if err != nil {
+							return fmt.Errorf("%s: %w", slnPath, err)
+						}
+						g := depgraph.New(sln)
+						if err := showGraph(g, sln.FilePath(), c.String("f"), os.Stdout, os.Stderr); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "check",
+				Usage: "report inconsistencies of the solution (exit status is non-zero when found)",
+				Action: func(c *cli.Context) error {
+					slnPaths, err := solution.Find(c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					count := 0
+					for _, slnPath := range slnPaths {
+						sln, err := solution.New(slnPath)
+						if err != nil {
+							return fmt.Errorf("%s: %w", slnPath, err)
+						}
+						count += checkSolution(sln, os.Stdout)
+					}
+					if count > 0 {
+						return fmt.Errorf("%d problem(s) found", count)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "items",
+				Usage: "list up items (ex. ClCompile, Compile) of the projects",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "t",
+						Usage: "show only items of the type",
+					},
+					&cli.StringFlag{
+						Name:  "c",
+						Usage: "show only the configuration (ex. \"Release|x64\")",
+					},
+					&cli.BoolFlag{
+						Name:  "m",
+						Usage: "show metadata of items",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
+					if err != nil {
+						return err
+					}
+					return showItems(sln.Solution, sln.DevenvPath,
+						c.String("t"), c.String("c"), c.Bool("m"),
+						os.Stdout, getWarningOut(c))
+				},
+			},
 			{
 				Name:  "showver",
 				Usage: "Show the version information for executables given by parameters",
@@ -325,17 +577,77 @@ func mains() error {
 					return nil
 				},
 			},
+			{
+				Name:  "imports",
+				Usage: "show the files imported by the projects",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "c",
+						Usage: "show only the configuration (ex. \"Release|x64\")",
+					},
+					propertyOption,
+				},
+				Action: func(c *cli.Context) error {
+					sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
+					if err != nil {
+						return err
+					}
+					properties, err := getProperties(c)
+					if err != nil {
+						return err
+					}
+					return showImports(sln.Solution, sln.DevenvPath, c.String("c"),
+						properties, os.Stdout, getWarningOut(c))
+				},
+			},
+			{
+				Name:      "why",
+				Usage:     "show how the value of the property is decided",
+				ArgsUsage: "[SOLUTION] PROPERTY",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "c",
+						Usage: "show only the configuration (ex. \"Release|x64\")",
+					},
+					propertyOption,
+				},
+				Action: func(c *cli.Context) error {
+					sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
+					if err != nil {
+						return err
+					}
+					properties, err := getProperties(c)
+					if err != nil {
+						return err
+					}
+					for _, s := range c.Args().Slice() {
+						if !solution.IsSolutionFile(s) {
+							err := why(sln.Solution, sln.DevenvPath, s, c.String("c"),
+								properties, os.Stdout, getWarningOut(c))
+							if err != nil {
+								return fmt.Errorf("%s: %w", sln.Path, err)
+							}
+						}
+					}
+					return nil
+				},
+			},
 			{
 				Name:  "eval",
 				Usage: "eval the equation given by parameter",
+				Flags: []cli.Flag{propertyOption},
 				Action: func(c *cli.Context) error {
 					sln, err := seekOneSolution(context2flag(c), c.Args().Slice(), getVerboseOut(c))
 					if err != nil {
 						return err
 					}
+					properties, err := getProperties(c)
+					if err != nil {
+						return err
+					}
 					for _, s := range c.Args().Slice() {
-						if !strings.HasSuffix(s, ".sln") {
-							if err := eval(sln.Solution, sln.DevenvPath, s); err != nil {
+						if !solution.IsSolutionFile(s) {
+							if err := eval(sln.Solution, sln.DevenvPath, s, properties); err != nil {
 								return fmt.Errorf("%s: %w", sln.Path, err)
 							}
 						}
@@ -345,7 +657,11 @@ func mains() error {
 			},
 		},
 	}
-	return app.Run(os.Args)
+	return app
+}
+
+func mains() error {
+	return newApp().Run(os.Args)
 }
 
 func main() {
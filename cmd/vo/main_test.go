@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// TestWhyArgs tests the invocation written in README:
+// vo why -c "Release|x64" OutDir
+func TestWhyArgs(t *testing.T) {
+	app := newApp()
+	var config, args string
+	for _, cmd := range app.Commands {
+		if cmd.Name == "why" {
+			cmd.Action = func(c *cli.Context) error {
+				config = c.String("c")
+				args = strings.Join(c.Args().Slice(), " ")
+				return nil
+			}
+		}
+	}
+	if err := app.Run([]string{"vo", "why", "-c", "Release|x64", "OutDir"}); err != nil {
+		t.Fatal(err)
+	}
+	if config != "Release|x64" || args != "OutDir" {
+		t.Fatalf("config=%s args=%s", config, args)
+	}
+}
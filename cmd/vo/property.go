@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+var propertyOption = &cli.StringSliceFlag{
+	Name:  "p",
+	Usage: "set the global property for the projects (ex. -p Name=Value)",
+}
+
+// getProperties returns the properties given by -p Name=Value.
+// Like MSBuild, "-p A=1;B=2" sets the both.
+func getProperties(c *cli.Context) (map[string]string, error) {
+	var properties map[string]string
+	for _, arg := range c.StringSlice("p") {
+		for _, s := range strings.Split(arg, ";") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(s, "=")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("-p %s: not Name=Value", s)
+			}
+			if properties == nil {
+				properties = map[string]string{}
+			}
+			properties[name] = value
+		}
+	}
+	return properties, nil
+}
+
+// findMSBuild returns MSBuild.exe of Visual Studio which devenv.com belongs to.
+func findMSBuild(devenvPath string) (string, error) {
+	msbuildDir := filepath.Join(filepath.Dir(devenvPath), `..\..\MSBuild`)
+	for _, version := range []string{"Current", "15.0"} {
+		path := filepath.Join(msbuildDir, version, "Bin", "MSBuild.exe")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("MSBuild.exe not found (required for -p)")
+}
+
+// msbuildArgs makes the parameters of MSBuild.exe equivalent to
+// devenv.com SOLUTION /build CONFIG.
+func msbuildArgs(slnPath, action, config string, properties map[string]string) []string {
+	target := "Build"
+	if action == "/rebuild" {
+		target = "Rebuild"
+	}
+	args := []string{slnPath, "/t:" + target}
+	if config != "" {
+		configuration, platform, _ := strings.Cut(config, "|")
+		args = append(args, "/p:Configuration="+configuration)
+		if platform != "" {
+			args = append(args, "/p:Platform="+platform)
+		}
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "/p:"+name+"="+properties[name])
+	}
+	return args
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/hymkor/vo/internal/solution"
+)
+
+// splitSolutionArgs separates the solution files and the others.
+func splitSolutionArgs(args []string) (slnArgs, others []string) {
+	for _, s := range args {
+		if solution.IsSolutionFile(s) {
+			slnArgs = append(slnArgs, s)
+		} else {
+			others = append(others, s)
+		}
+	}
+	return
+}
+
+// loadOneSolution reads the solution without looking for Visual Studio.
+func loadOneSolution(args []string) (*solution.Solution, error) {
+	slnPaths, err := solution.Find(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(slnPaths) <= 0 {
+		return nil, errors.New("no solution files")
+	}
+	if len(slnPaths) >= 2 {
+		return nil, fmt.Errorf("%s: too many solution files", strings.Join(slnPaths, " "))
+	}
+	sln, err := solution.New(slnPaths[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", slnPaths[0], err)
+	}
+	return sln, nil
+}
+
+func saveSolution(c *cli.Context, sln *solution.Solution) error {
+	if c.Bool("n") {
+		_, err := sln.WriteTo(os.Stdout)
+		return err
+	}
+	return sln.Save()
+}
+
+func slnAdd(c *cli.Context) error {
+	slnArgs, projArgs := splitSolutionArgs(c.Args().Slice())
+	if len(projArgs) <= 0 {
+		return errors.New("no project files")
+	}
+	sln, err := loadOneSolution(slnArgs)
+	if err != nil {
+		return err
+	}
+	for _, projPath := range projArgs {
+		proj, err := sln.AddProject(projPath, c.String("f"))
+		if err != nil {
+			return fmt.Errorf("%s: %w", sln.Path, err)
+		}
+		fmt.Fprintf(getVerboseOut(c), "%s: add %s %s\n", sln.Path, proj.Path, proj.GUID)
+	}
+	return saveSolution(c, sln)
+}
+
+// findProject looks for the project by the path or the name.
+func findProject(sln *solution.Solution, arg string) *solution.Project {
+	if relPath, err := sln.RelPath(arg); err == nil {
+		for _, p := range sln.Projects {
+			if strings.EqualFold(p.Path, relPath) {
+				return p
+			}
+		}
+	}
+	for _, p := range sln.Projects {
+		if strings.EqualFold(p.Name, arg) {
+			return p
+		}
+	}
+	return nil
+}
+
+func slnRemove(c *cli.Context) error {
+	slnArgs, projArgs := splitSolutionArgs(c.Args().Slice())
+	if len(projArgs) <= 0 {
+		return errors.New("no project files")
+	}
+	sln, err := loadOneSolution(slnArgs)
+	if err != nil {
+		return err
+	}
+	for _, arg := range projArgs {
+		proj := findProject(sln, arg)
+		if proj == nil {
+			return fmt.Errorf("%s: %s: project not found", sln.Path, arg)
+		}
+		sln.RemoveProject(proj)
+		fmt.Fprintf(getVerboseOut(c), "%s: remove %s %s\n", sln.Path, proj.Path, proj.GUID)
+	}
+	return saveSolution(c, sln)
+}
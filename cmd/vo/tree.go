@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hymkor/vo/internal/solution"
+)
+
+func existsFile(fname string) bool {
+	_, err := os.Stat(fname)
+	return err == nil
+}
+
+// showTree prints the solution folders and the projects in them.
+func showTree(sln *solution.Solution, w io.Writer) {
+	fmt.Fprintf(w, "%s\n", sln.FilePath())
+	visited := map[string]struct{}{}
+	var walk func(parentGUID string, indent string)
+	walk = func(parentGUID string, indent string) {
+		for _, p := range sln.Children(parentGUID) {
+			guid := strings.ToUpper(p.GUID)
+			if _, ok := visited[guid]; ok {
+				continue
+			}
+			visited[guid] = struct{}{}
+			if p.IsFolder() {
+				fmt.Fprintf(w, "%s%s\\\n", indent, p.Name)
+				walk(p.GUID, indent+"  ")
+				continue
+			}
+			fmt.Fprintf(w, "%s%s (%s) %s", indent, p.Name, solution.TypeName(p.TypeGUID), p.Path)
+			if !existsFile(sln.ProjectPath(p)) {
+				io.WriteString(w, " [not found]")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	walk("", "  ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hymkor/go-sortedkeys"
+
+	"github.com/hymkor/vo/internal/projs"
+	"github.com/hymkor/vo/internal/solution"
+)
+
+func writeAssignment(a *projs.Assignment, w io.Writer) {
+	where := "(" + a.Note + ")"
+	if a.File != "" {
+		where = fmt.Sprintf("%s(%d,%d)", a.File, a.Line, a.Col)
+	}
+	fmt.Fprintf(w, "    %s: %s=%s\n", where, a.Name, a.Value)
+	for _, cond := range a.Conditions {
+		fmt.Fprintf(w, "      if %s\n", strings.TrimSpace(cond))
+	}
+	if a.File != "" && a.Note != "" {
+		if a.Applied {
+			fmt.Fprintf(w, "      (%s)\n", a.Note)
+		} else {
+			fmt.Fprintf(w, "      (ignored: %s)\n", a.Note)
+		}
+	}
+}
+
+// why prints how the value of the property `name` is decided
+// for each project and configuration.
+func why(sln *solution.Solution, devenvPath, name, config string, overrides map[string]string, w, warning io.Writer) error {
+	projToConfigToProject := getProjToConfigToProject(sln, devenvPath, overrides, warning)
+	for pair1 := sortedkeys.New(projToConfigToProject); pair1.Range(); {
+		fmt.Fprintf(w, "%s:\n", pair1.Key)
+		for pair2 := sortedkeys.New(pair1.Value); pair2.Range(); {
+			if config != "" && !strings.EqualFold(config, pair2.Key) {
+				continue
+			}
+			project := pair2.Value
+			value, ok := project.Properties.Lookup(name)
+			if !ok {
+				if env, ok := os.LookupEnv(name); ok {
+					fmt.Fprintf(w, "  %s: %s=%s (environment variable)\n", pair2.Key, name, env)
+				} else {
+					fmt.Fprintf(w, "  %s: %s is not defined\n", pair2.Key, name)
+				}
+			} else {
+				fmt.Fprintf(w, "  %s: %s=%s\n", pair2.Key, name, value)
+			}
+			for _, a := range project.History(name) {
+				writeAssignment(a, w)
+			}
+		}
+	}
+	return nil
+}
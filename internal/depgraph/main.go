@@ -0,0 +1,238 @@
+package depgraph
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hymkor/vo/internal/solution"
+)
+
+const (
+	// KindSolution is the dependency written in ProjectSection(ProjectDependencies)
+	KindSolution = "ProjectDependencies"
+	// KindReference is the dependency written as <ProjectReference>
+	KindReference = "ProjectReference"
+)
+
+// Node is a project.
+type Node struct {
+	Name     string
+	Path     string // relative to the solution directory
+	GUID     string
+	External bool // referred by ProjectReference, but not in the solution
+}
+
+// Edge means `From` depends on `To`.
+type Edge struct {
+	From *Node
+	To   *Node
+	Kind string
+}
+
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+}
+
+type xmlProjectReferences struct {
+	XMLName    xml.Name `xml:"Project"`
+	References []struct {
+		Include string `xml:"Include,attr"`
+	} `xml:"ItemGroup>ProjectReference"`
+}
+
+func readProjectReferences(fname string) ([]string, error) {
+	bin, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var doc xmlProjectReferences
+	if err := xml.Unmarshal(bin, &doc); err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(doc.References))
+	for _, r := range doc.References {
+		result = append(result, r.Include)
+	}
+	return result, nil
+}
+
+func pathKey(s string) string {
+	return strings.ToLower(filepath.Clean(filepath.FromSlash(strings.ReplaceAll(s, `\`, "/"))))
+}
+
+// New builds the dependency graph of the projects in the solution.
+// Project files which can not be read are treated as having no references.
+func New(sln *solution.Solution) *Graph {
+	g := &Graph{}
+	dir := filepath.Dir(sln.Path)
+	byGUID := map[string]*Node{}
+	byPath := map[string]*Node{}
+	for _, p := range sln.Projects {
+		if p.IsFolder() {
+			continue
+		}
+		node := &Node{Name: p.Name, Path: p.Path, GUID: p.GUID}
+		g.Nodes = append(g.Nodes, node)
+		byGUID[strings.ToUpper(p.GUID)] = node
+		byPath[pathKey(sln.ProjectPath(p))] = node
+	}
+	seen := map[[2]*Node]struct{}{}
+	addEdge := func(from, to *Node, kind string) {
+		key := [2]*Node{from, to}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		g.Edges = append(g.Edges, &Edge{From: from, To: to, Kind: kind})
+	}
+	for _, p := range sln.Projects {
+		from, ok := byGUID[strings.ToUpper(p.GUID)]
+		if !ok {
+			continue
+		}
+		if deps := p.Section("ProjectDependencies"); deps != nil {
+			for _, e := range deps.Entries {
+				if to, ok := byGUID[strings.ToUpper(e.Key)]; ok {
+					addEdge(from, to, KindSolution)
+				}
+			}
+		}
+		projPath := sln.ProjectPath(p)
+		refs, err := readProjectReferences(projPath)
+		if err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			refPath := filepath.Join(filepath.Dir(projPath),
+				filepath.FromSlash(strings.ReplaceAll(ref, `\`, "/")))
+			to, ok := byPath[pathKey(refPath)]
+			if !ok {
+				rel, err := filepath.Rel(dir, refPath)
+				if err != nil {
+					rel = refPath
+				}
+				base := filepath.Base(refPath)
+				to = &Node{
+					Name:     strings.TrimSuffix(base, filepath.Ext(base)),
+					Path:     strings.ReplaceAll(rel, "/", `\`),
+					External: true,
+				}
+				g.Nodes = append(g.Nodes, to)
+				byPath[pathKey(refPath)] = to
+			}
+			addEdge(from, to, KindReference)
+		}
+	}
+	return g
+}
+
+// DependsOn returns the nodes which `node` depends on.
+func (g *Graph) DependsOn(node *Node) []*Node {
+	var result []*Node
+	for _, e := range g.Edges {
+		if e.From == node {
+			result = append(result, e.To)
+		}
+	}
+	return result
+}
+
+// Cycles returns the groups of the projects depending on each other.
+// (strongly connected components by Tarjan's algorithm)
+func (g *Graph) Cycles() [][]*Node {
+	index := map[*Node]int{}
+	lowlink := map[*Node]int{}
+	onStack := map[*Node]bool{}
+	var stack []*Node
+	var result [][]*Node
+	counter := 0
+
+	var connect func(v *Node)
+	connect = func(v *Node) {
+		index[v] = counter
+		lowlink[v] = counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+		selfLoop := false
+		for _, w := range g.DependsOn(v) {
+			if w == v {
+				selfLoop = true
+			}
+			if _, ok := index[w]; !ok {
+				connect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && index[w] < lowlink[v] {
+				lowlink[v] = index[w]
+			}
+		}
+		if lowlink[v] != index[v] {
+			return
+		}
+		var component []*Node
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			// reverse to the order of the discovery
+			for i, j := 0, len(component)-1; i < j; i, j = i+1, j-1 {
+				component[i], component[j] = component[j], component[i]
+			}
+			result = append(result, component)
+		}
+	}
+	for _, v := range g.Nodes {
+		if _, ok := index[v]; !ok {
+			connect(v)
+		}
+	}
+	return result
+}
+
+// ErrCycle is returned by BuildOrder when the projects depend on each other.
+var ErrCycle = errors.New("dependency cycle detected")
+
+// BuildOrder returns the projects sorted so that each project comes
+// after the projects it depends on. Independent projects keep the order
+// of the solution.
+func (g *Graph) BuildOrder() ([]*Node, error) {
+	rest := map[*Node]int{}
+	for _, e := range g.Edges {
+		rest[e.From]++
+	}
+	done := map[*Node]bool{}
+	result := make([]*Node, 0, len(g.Nodes))
+	for len(result) < len(g.Nodes) {
+		progress := false
+		for _, v := range g.Nodes {
+			if done[v] || rest[v] > 0 {
+				continue
+			}
+			done[v] = true
+			result = append(result, v)
+			progress = true
+			for _, e := range g.Edges {
+				if e.To == v {
+					rest[e.From]--
+				}
+			}
+			break
+		}
+		if !progress {
+			return result, ErrCycle
+		}
+	}
+	return result, nil
+}
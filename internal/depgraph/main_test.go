@@ -0,0 +1,89 @@
+package depgraph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hymkor/vo/internal/solution"
+)
+
+const sampleSolution = "" +
+	"Microsoft Visual Studio Solution File, Format Version 12.00\r\n" +
+	"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"App\", \"App\\App.csproj\", \"{11111111-1111-1111-1111-111111111111}\"\r\n" +
+	"EndProject\r\n" +
+	"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Lib\", \"Lib\\Lib.csproj\", \"{22222222-2222-2222-2222-222222222222}\"\r\n" +
+	"\tProjectSection(ProjectDependencies) = postProject\r\n" +
+	"\t\t{33333333-3333-3333-3333-333333333333} = {33333333-3333-3333-3333-333333333333}\r\n" +
+	"\tEndProjectSection\r\n" +
+	"EndProject\r\n" +
+	"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Base\", \"Base\\Base.csproj\", \"{33333333-3333-3333-3333-333333333333}\"\r\n" +
+	"EndProject\r\n" +
+	"Global\r\n" +
+	"EndGlobal\r\n"
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func project(refs ...string) string {
+	s := "<Project><ItemGroup>"
+	for _, r := range refs {
+		s += `<ProjectReference Include="` + r + `" />`
+	}
+	return s + "</ItemGroup></Project>"
+}
+
+func names(nodes []*Node) string {
+	s := ""
+	for _, n := range nodes {
+		if s != "" {
+			s += " "
+		}
+		s += n.Name
+	}
+	return s
+}
+
+func TestBuildOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sample.sln"), sampleSolution)
+	writeFile(t, filepath.Join(dir, "App", "App.csproj"), project(`..\Lib\Lib.csproj`, `..\Ext\Ext.csproj`))
+	writeFile(t, filepath.Join(dir, "Lib", "Lib.csproj"), project())
+	writeFile(t, filepath.Join(dir, "Base", "Base.csproj"), project())
+
+	sln, err := solution.New(filepath.Join(dir, "sample.sln"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(sln)
+	if len(g.Nodes) != 4 || !g.Nodes[3].External || g.Nodes[3].Path != `Ext\Ext.csproj` {
+		t.Fatalf("Nodes=%s", names(g.Nodes))
+	}
+	order, err := g.BuildOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := names(order); s != "Base Lib Ext App" {
+		t.Fatalf("BuildOrder=%s", s)
+	}
+	if cycles := g.Cycles(); len(cycles) != 0 {
+		t.Fatalf("Cycles=%v", cycles)
+	}
+
+	writeFile(t, filepath.Join(dir, "Base", "Base.csproj"), project(`..\App\App.csproj`))
+	g = New(sln)
+	if _, err := g.BuildOrder(); err != ErrCycle {
+		t.Fatalf("BuildOrder()=%v", err)
+	}
+	cycles := g.Cycles()
+	if len(cycles) != 1 || names(cycles[0]) != "App Lib Base" {
+		t.Fatalf("Cycles=%v", cycles)
+	}
+}
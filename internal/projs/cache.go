@@ -0,0 +1,58 @@
+package projs
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// The parsed documents are shared by the evaluations of all projects and
+// configurations, since files like Microsoft.Cpp.Default.props are imported
+// by every project. The elements are never modified after parsing,
+// so they can be used by the evaluations running concurrently.
+
+type cachedDocument struct {
+	modTime time.Time
+	size    int64
+	root    *element
+	err     error
+}
+
+var documentCache = struct {
+	sync.Mutex
+	docs map[string]*cachedDocument
+}{
+	docs: map[string]*cachedDocument{},
+}
+
+// loadXML returns the root element of the file `fname`.
+// When the file is not changed since the last call, the cached one is returned.
+func loadXML(fname string) (*element, error) {
+	stat, err := os.Stat(fname)
+	if err != nil {
+		return nil, err
+	}
+	documentCache.Lock()
+	doc, ok := documentCache.docs[fname]
+	documentCache.Unlock()
+	if ok && doc.modTime.Equal(stat.ModTime()) && doc.size == stat.Size() {
+		return doc.root, doc.err
+	}
+
+	fd, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseXML(fd)
+	fd.Close()
+
+	documentCache.Lock()
+	documentCache.docs[fname] = &cachedDocument{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		root:    root,
+		err:     err,
+	}
+	documentCache.Unlock()
+	return root, err
+}
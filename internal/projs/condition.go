@@ -0,0 +1,545 @@
+package projs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The condition language of MSBuild
+// https://learn.microsoft.com/visualstudio/msbuild/msbuild-conditions
+//
+//   or         := and { "or" and }
+//   and        := not { "and" not }
+//   not        := "!" not | comparison
+//   comparison := factor [ ("=="|"!="|"<"|">"|"<="|">=") factor ]
+//   factor     := "(" or ")" | 'string' | $(property) | @(item) | %(metadata)
+//               | number | word | function "(" [ factor { "," factor } ] ")"
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenString
+	tokenExpand // unquoted $(...), @(...) or %(...)
+	tokenWord   // numbers, true, false, and, or, function names ...
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// readBalanced reads `$(...)` after `$` with nested parentheses.
+func readBalanced(sc io.RuneScanner, mark rune) (string, error) {
+	var buffer strings.Builder
+	buffer.WriteRune(mark)
+	r, _, err := sc.ReadRune()
+	if err != nil || r != '(' {
+		return "", fmt.Errorf("`(` expected after `%c`", mark)
+	}
+	buffer.WriteRune(r)
+	depth := 1
+	var quote rune
+	for depth > 0 {
+		r, _, err = sc.ReadRune()
+		if err != nil {
+			return "", fmt.Errorf("`%s` is not closed", buffer.String())
+		}
+		buffer.WriteRune(r)
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+		} else if r == '\'' || r == '"' || r == '`' {
+			quote = r
+		} else if r == '(' {
+			depth++
+		} else if r == ')' {
+			depth--
+		}
+	}
+	return buffer.String(), nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+'
+}
+
+func readToken(sc io.RuneScanner) (token, error) {
+	r, err := read1st(sc)
+	if err == io.EOF {
+		return token{kind: tokenEOF}, nil
+	}
+	if err != nil {
+		return token{}, err
+	}
+	switch r {
+	case '\'':
+		sc.UnreadRune()
+		s, err := evalString(sc)
+		if err != nil {
+			return token{}, errors.New("string literal is not closed")
+		}
+		return token{kind: tokenString, text: s}, nil
+	case '$', '@', '%':
+		s, err := readBalanced(sc, r)
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenExpand, text: s}, nil
+	case '(', ')', ',':
+		return token{kind: tokenOperator, text: string(r)}, nil
+	case '=', '!', '<', '>':
+		next, _, err := sc.ReadRune()
+		if err == nil && next == '=' {
+			return token{kind: tokenOperator, text: string(r) + "="}, nil
+		}
+		if err == nil {
+			sc.UnreadRune()
+		}
+		if r == '=' {
+			return token{}, errors.New("`=` is not an operator (use `==`)")
+		}
+		return token{kind: tokenOperator, text: string(r)}, nil
+	}
+	if !isWordRune(r) {
+		return token{}, fmt.Errorf("unexpected character `%c`", r)
+	}
+	var buffer strings.Builder
+	buffer.WriteRune(r)
+	for {
+		r, _, err = sc.ReadRune()
+		if err != nil {
+			break
+		}
+		if !isWordRune(r) {
+			sc.UnreadRune()
+			break
+		}
+		buffer.WriteRune(r)
+	}
+	return token{kind: tokenWord, text: buffer.String()}, nil
+}
+
+func tokenize(s string) ([]token, error) {
+	sc := strings.NewReader(s)
+	var tokens []token
+	for {
+		t, err := readToken(sc)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type conditionParser struct {
+	tokens []token
+	pos    int
+	expand func(string) string
+	exists func(string) bool
+	// skipping is positive while parsing an operand whose result is
+	// already decided by `and` or `or`: it is parsed but not evaluated.
+	skipping int
+}
+
+// skip parses an operand by `parse` without evaluating it.
+// Only syntax errors are reported.
+func (p *conditionParser) skip(parse func() (value, error)) error {
+	p.skipping++
+	defer func() { p.skipping-- }()
+	_, err := parse()
+	return err
+}
+
+// toBool is same as value.toBool, but no error occurs while skipping.
+func (p *conditionParser) toBool(v value) (bool, error) {
+	b, err := v.toBool()
+	if err != nil && p.skipping > 0 {
+		return false, nil
+	}
+	return b, err
+}
+
+func (p *conditionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *conditionParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *conditionParser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == tokenOperator && t.text == op
+}
+
+// value is the result of a factor: a string with the information
+// whether it was a literal or comes from a comparison/logical operator.
+type value struct {
+	text     string
+	isBool   bool
+	boolean  bool
+	isQuoted bool
+}
+
+func (v value) toBool() (bool, error) {
+	if v.isBool {
+		return v.boolean, nil
+	}
+	switch strings.ToLower(v.text) {
+	case "true", "on", "yes", "!false", "!off", "!no":
+		return true, nil
+	case "false", "off", "no", "!true", "!on", "!yes":
+		return false, nil
+	}
+	return false, fmt.Errorf("`%s` is not a boolean", v.text)
+}
+
+func (p *conditionParser) parseOr() (value, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		l, err := p.toBool(left)
+		if err != nil {
+			return left, err
+		}
+		if l {
+			if err := p.skip(p.parseAnd); err != nil {
+				return value{}, err
+			}
+			left = value{isBool: true, boolean: true}
+			continue
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		r, err := p.toBool(right)
+		if err != nil {
+			return right, err
+		}
+		left = value{isBool: true, boolean: r}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (value, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return left, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		l, err := p.toBool(left)
+		if err != nil {
+			return left, err
+		}
+		if !l {
+			if err := p.skip(p.parseNot); err != nil {
+				return value{}, err
+			}
+			left = value{isBool: true, boolean: false}
+			continue
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return right, err
+		}
+		r, err := p.toBool(right)
+		if err != nil {
+			return right, err
+		}
+		left = value{isBool: true, boolean: r}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNot() (value, error) {
+	if p.isOperator("!") {
+		p.next()
+		v, err := p.parseNot()
+		if err != nil {
+			return v, err
+		}
+		b, err := p.toBool(v)
+		if err != nil {
+			return v, err
+		}
+		return value{isBool: true, boolean: !b}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (value, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return left, err
+	}
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", ">", "<=", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseFactor()
+	if err != nil {
+		return right, err
+	}
+	if p.skipping > 0 {
+		return value{isBool: true}, nil
+	}
+	result, err := compare(t.text, left, right)
+	if err != nil {
+		return value{}, err
+	}
+	return value{isBool: true, boolean: result}, nil
+}
+
+func (p *conditionParser) parseFactor() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenEOF:
+		return value{}, errors.New("unexpected end of the condition")
+	case tokenString:
+		if p.skipping > 0 {
+			return value{text: t.text, isQuoted: true}, nil
+		}
+		return value{text: p.expand(t.text), isQuoted: true}, nil
+	case tokenExpand:
+		if p.skipping > 0 {
+			return value{text: t.text}, nil
+		}
+		return value{text: p.expand(t.text)}, nil
+	case tokenOperator:
+		if t.text != "(" {
+			return value{}, fmt.Errorf("unexpected `%s`", t.text)
+		}
+		v, err := p.parseOr()
+		if err != nil {
+			return v, err
+		}
+		if !p.isOperator(")") {
+			return v, errors.New("`)` expected")
+		}
+		p.next()
+		return v, nil
+	}
+	if !p.isOperator("(") {
+		return value{text: t.text}, nil
+	}
+	p.next()
+	var args []value
+	if p.isOperator(")") {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return arg, err
+			}
+			args = append(args, arg)
+			if p.isOperator(")") {
+				p.next()
+				break
+			}
+			if !p.isOperator(",") {
+				return value{}, fmt.Errorf("`,` or `)` expected in %s()", t.text)
+			}
+			p.next()
+		}
+	}
+	return p.callFunction(t.text, args)
+}
+
+func (p *conditionParser) callFunction(name string, args []value) (value, error) {
+	if p.skipping > 0 {
+		return value{isBool: true}, nil
+	}
+	if len(args) != 1 {
+		return value{}, fmt.Errorf("%s() requires one argument", name)
+	}
+	switch strings.ToLower(name) {
+	case "exists":
+		path := strings.TrimSpace(args[0].text)
+		return value{isBool: true, boolean: path != "" && p.exists(path)}, nil
+	case "hastrailingslash":
+		s := args[0].text
+		return value{isBool: true, boolean: strings.HasSuffix(s, `\`) || strings.HasSuffix(s, "/")}, nil
+	}
+	return value{}, fmt.Errorf("%s(): unknown function", name)
+}
+
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") {
+		n, err := strconv.ParseInt(s[2:], 16, 64)
+		return float64(n), err == nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+// parseVersion parses `1.2`, `1.2.3` or `1.2.3.4`
+func parseVersion(s string) ([]int, bool) {
+	fields := strings.Split(strings.TrimSpace(s), ".")
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, false
+	}
+	result := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		result = append(result, n)
+	}
+	return result, true
+}
+
+func compareVersion(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		// a missing component is less than zero as System.Version
+		x, y := -1, -1
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareResult(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	default:
+		return c >= 0
+	}
+}
+
+func compare(op string, left, right value) (bool, error) {
+	if x, ok := parseNumber(left.text); ok && !left.isBool {
+		if y, ok := parseNumber(right.text); ok && !right.isBool {
+			c := 0
+			if x < y {
+				c = -1
+			} else if x > y {
+				c = 1
+			}
+			return compareResult(op, c), nil
+		}
+	}
+	if op == "==" || op == "!=" {
+		if left.isBool || right.isBool {
+			l, err1 := left.toBool()
+			r, err2 := right.toBool()
+			if err1 == nil && err2 == nil {
+				return (l == r) == (op == "=="), nil
+			}
+		}
+		if left.isBool {
+			left.text = strconv.FormatBool(left.boolean)
+		}
+		if right.isBool {
+			right.text = strconv.FormatBool(right.boolean)
+		}
+		return strings.EqualFold(left.text, right.text) == (op == "=="), nil
+	}
+	if x, ok := parseVersion(left.text); ok {
+		if y, ok := parseVersion(right.text); ok {
+			return compareResult(op, compareVersion(x, y)), nil
+		}
+	}
+	return false, fmt.Errorf("`%s %s %s` can not compare as numbers", left.text, op, right.text)
+}
+
+func existsFile(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// evalConditionWith evaluates the condition `s`.
+// `expand` is called for the quoted strings and unquoted $(...)
+func evalConditionWith(s string, expand func(string) string) (bool, error) {
+	return evalConditionIn(s, "", expand)
+}
+
+// evalConditionIn is same as evalConditionWith, but relative paths
+// of Exists() are resolved from the directory `dir`.
+func evalConditionIn(s, dir string, expand func(string) string) (bool, error) {
+	if strings.TrimSpace(s) == "" {
+		return true, nil
+	}
+	tokens, err := tokenize(s)
+	if err != nil {
+		return false, err
+	}
+	exists := func(path string) bool {
+		path = nativePath(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return existsFile(path)
+	}
+	p := &conditionParser{tokens: tokens, expand: expand, exists: exists}
+	v, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return false, fmt.Errorf("unexpected `%s`", t.text)
+	}
+	return v.toBool()
+}
+
+// EvalCondition evaluates the condition `s` whose properties are already expanded.
+func EvalCondition(s string) (bool, error) {
+	return evalConditionWith(s, func(s string) string { return s })
+}
@@ -0,0 +1,75 @@
+package projs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvalConditionGrammar(t *testing.T) {
+	dir := t.TempDir()
+	properties := NewProperties(map[string]string{
+		"Platform":            "x64",
+		"Configuration":       "Release",
+		"VisualStudioVersion": "16.0",
+		"Dir":                 dir + string(filepath.Separator),
+		"Empty":               "",
+		"Flag":                "true",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "a.props"), []byte{}, 0666); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		cond   string
+		expect bool
+	}{
+		{"", true},
+		{"'$(Platform)'=='x64'", true},
+		{"'$(Platform)'=='X64'", true},
+		{"'$(Platform)' != 'x64'", false},
+		{"$(Platform) == x64", true},
+		{"'$(Configuration)|$(Platform)'=='Release|x64'", true},
+		{"'$(Platform)'=='x64' and '$(Configuration)'=='Debug'", false},
+		{"'$(Platform)'=='x64' or '$(Configuration)'=='Debug'", true},
+		{"'$(Platform)'=='Win32' or ('$(Configuration)'=='Release' and !('$(Empty)'!=''))", true},
+		{"!$(Flag)", false},
+		{"$(Flag) AND true", true},
+		{"'$(VisualStudioVersion)' >= '15.0'", true},
+		{"$(VisualStudioVersion) < 10", false},
+		{"'16.10.1' > '16.9'", true},
+		{"'10' == '10.0'", true},
+		{"0x10 == 16", true},
+		{"Exists('$(Dir)a.props')", true},
+		{"!exists('$(Dir)b.props')", true},
+		{"Exists('$(Empty)')", false},
+		{"HasTrailingSlash('$(Dir)')", true},
+		{"hastrailingslash('$(Platform)')", false},
+		{"'$(Empty)' == '' or '$(Empty)' < '16.0'", true},
+		{"'$(Empty)' != '' and '$(Empty)' < '16.0'", false},
+		{"true or Unknown('a')", true},
+		{"false and ('a' < 'b' or 'abc')", false},
+	} {
+		result, err := properties.EvalCondition(c.cond)
+		if err != nil {
+			t.Fatalf("%s: %s", c.cond, err)
+		}
+		if result != c.expect {
+			t.Fatalf("%s: %v (expected %v)", c.cond, result, c.expect)
+		}
+	}
+	for _, cond := range []string{
+		"'a' = 'b'",
+		"'a' == ",
+		"('a' == 'a'",
+		"'abc",
+		"'a' < 'b'",
+		"Unknown('a')",
+		"'a'",
+		"true or ('a' ==",
+		"false and 'a' < 'b' or 'c' < 'd'",
+	} {
+		if _, err := properties.EvalCondition(cond); err == nil {
+			t.Fatalf("%s: error expected", cond)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package projs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Directory.Build.props and Directory.Build.targets
+// https://learn.microsoft.com/visualstudio/msbuild/customize-by-directory
+//
+// MSBuild imports them from Microsoft.Common.props and Microsoft.Common.targets.
+// Since those files are not always available, they are imported at the
+// Import elements of the files which import Microsoft.Common.* and at
+// Sdk.props and Sdk.targets.
+
+var importsCommonProps = []string{
+	"Microsoft.Common.props",
+	"Microsoft.Cpp.Default.props",
+}
+
+var importsCommonTargets = []string{
+	"Microsoft.Common.targets",
+	"Microsoft.CSharp.targets",
+	"Microsoft.VisualBasic.targets",
+	"Microsoft.FSharp.targets",
+	"Microsoft.Cpp.targets",
+}
+
+func isImportOf(e *element, names []string) bool {
+	value, _ := e.attr("Project")
+	value = strings.TrimSpace(value)
+	for _, name := range names {
+		if strings.HasSuffix(strings.ToLower(value), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importDirectoryBuild imports Directory.Build.`kind` (props or targets)
+// found above the project directory unless Import Directory.Build.`kind` is false.
+func (ev *evaluator) importDirectoryBuild(kind string) {
+	if ev.dir == "" || ev.directoryBuild[kind] {
+		return
+	}
+	ev.directoryBuild[kind] = true
+
+	props := ev.project.Properties
+	title := "Props"
+	if kind == "targets" {
+		title = "Targets"
+	}
+	if strings.EqualFold(props.Get("ImportDirectoryBuild"+title), "false") {
+		return
+	}
+	path := props.Get("DirectoryBuild" + title + "Path")
+	if path == "" {
+		name := "Directory.Build." + kind
+		dir := fileAbove(ev.dir, name)
+		if dir == "" {
+			return
+		}
+		path = filepath.Join(dir, name)
+		ev.setDefault("DirectoryBuild"+title+"Path", path)
+	}
+	ev.importFile(nil, "Directory.Build."+kind, path)
+}
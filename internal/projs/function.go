@@ -0,0 +1,909 @@
+package projs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Property functions of MSBuild
+// https://learn.microsoft.com/visualstudio/msbuild/property-functions
+//
+//   $(Name)
+//   $(Name.Method(args).Method(args)...)
+//   $([Class]::Method(args).Method(args)...)
+
+type expander struct {
+	lookup     func(name string) (string, bool)
+	onNotFound func(name string) string
+	// items returns the items of the type for @(...). When nil, @(...) is left as it is.
+	items func(itemType string) []*Item
+	// item is referred by %(...). When nil, %(...) is left as it is.
+	item *Item
+}
+
+func (e *expander) notFound(name string) string {
+	if e.onNotFound != nil {
+		return e.onNotFound(name)
+	}
+	return ""
+}
+
+// findClose returns the index of `)` closing the `(` at text[start].
+func findClose(text string, start int) int {
+	depth := 0
+	var quote rune
+	for i, r := range text[start:] {
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return start + i
+			}
+		}
+	}
+	return -1
+}
+
+// nextMark returns the index of the first $(, @( or %( to be expanded.
+func (e *expander) nextMark(text string) int {
+	for i := 0; i+1 < len(text); i++ {
+		if text[i+1] != '(' {
+			continue
+		}
+		switch text[i] {
+		case '$':
+			return i
+		case '@':
+			if e.items != nil {
+				return i
+			}
+		case '%':
+			if e.item != nil {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expand replaces all $(...), @(...) and %(...) in `text`.
+func (e *expander) expand(text string) string {
+	var buffer strings.Builder
+	for {
+		i := e.nextMark(text)
+		if i < 0 {
+			buffer.WriteString(text)
+			return buffer.String()
+		}
+		end := findClose(text, i+1)
+		if end < 0 {
+			buffer.WriteString(text)
+			return buffer.String()
+		}
+		buffer.WriteString(text[:i])
+		body := text[i+2 : end]
+		switch text[i] {
+		case '$':
+			buffer.WriteString(e.evalProperty(body))
+		case '@':
+			buffer.WriteString(e.evalItemList(body))
+		case '%':
+			buffer.WriteString(e.item.metadataRef(body))
+		}
+		text = text[end+1:]
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+func readIdent(s string) (string, string) {
+	for i, r := range s {
+		if !isIdentRune(r) {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// readArgs reads `(a, b, ...)` at the top of `s` and returns
+// the expanded arguments and the rest of `s`.
+func (e *expander) readArgs(s string) ([]string, string, error) {
+	end := findClose(s, 0)
+	if end < 0 {
+		return nil, "", errors.New("`)` not found")
+	}
+	inner := s[1:end]
+	rest := s[end+1:]
+	var args []string
+	if strings.TrimSpace(inner) == "" {
+		return args, rest, nil
+	}
+	start := 0
+	depth := 0
+	var quote rune
+	for i, r := range inner + "," {
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, e.evalArg(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return args, rest, nil
+}
+
+func (e *expander) evalArg(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= 2 {
+		switch q := arg[0]; q {
+		case '\'', '"', '`':
+			if arg[len(arg)-1] == q {
+				return e.expand(arg[1 : len(arg)-1])
+			}
+		}
+	}
+	return e.expand(arg)
+}
+
+// evalProperty evaluates the inside of $(...)
+func (e *expander) evalProperty(body string) string {
+	value, err := e.evalPropertyFunction(strings.TrimSpace(body))
+	if err != nil {
+		return e.notFound(body)
+	}
+	return value
+}
+
+var errNotFound = errors.New("property not found")
+
+func (e *expander) evalPropertyFunction(body string) (string, error) {
+	var value string
+	var rest string
+	if strings.HasPrefix(body, "[") {
+		end := strings.Index(body, "]")
+		if end < 0 {
+			return "", errors.New("`]` not found")
+		}
+		class := strings.TrimSpace(body[1:end])
+		rest = strings.TrimSpace(body[end+1:])
+		if !strings.HasPrefix(rest, "::") {
+			return "", errors.New("`::` not found")
+		}
+		var member string
+		member, rest = readIdent(strings.TrimSpace(rest[2:]))
+		var args []string
+		hasArgs := false
+		if strings.HasPrefix(rest, "(") {
+			var err error
+			args, rest, err = e.readArgs(rest)
+			if err != nil {
+				return "", err
+			}
+			hasArgs = true
+		}
+		var err error
+		value, err = e.callStatic(class, member, args, hasArgs)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if strings.HasPrefix(strings.ToLower(body), "registry:") {
+			return "", nil
+		}
+		var name string
+		name, rest = readIdent(body)
+		if name == "" {
+			return "", fmt.Errorf("`%s`: invalid property", body)
+		}
+		var ok bool
+		value, ok = e.lookup(name)
+		if !ok {
+			if strings.TrimSpace(rest) == "" {
+				return e.notFound(name), nil
+			}
+			value = e.notFound(name)
+		}
+	}
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return value, nil
+		}
+		if rest[0] != '.' {
+			return "", fmt.Errorf("`%s`: unexpected", rest)
+		}
+		var member string
+		member, rest = readIdent(rest[1:])
+		var args []string
+		hasArgs := false
+		if strings.HasPrefix(rest, "(") {
+			var err error
+			args, rest, err = e.readArgs(rest)
+			if err != nil {
+				return "", err
+			}
+			hasArgs = true
+		}
+		var err error
+		value, err = callStringMethod(value, member, args, hasArgs)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+func argN(args []string, n int, name string) error {
+	if len(args) != n {
+		return fmt.Errorf("%s requires %d argument(s)", name, n)
+	}
+	return nil
+}
+
+func toInt(s string) (int, error) {
+	return strconv.Atoi(strings.TrimSpace(s))
+}
+
+func callStringMethod(s, method string, args []string, hasArgs bool) (string, error) {
+	switch strings.ToLower(method) {
+	case "length":
+		return strconv.Itoa(len([]rune(s))), nil
+	case "tolower", "tolowerinvariant":
+		return strings.ToLower(s), nil
+	case "toupper", "toupperinvariant":
+		return strings.ToUpper(s), nil
+	case "tostring":
+		return s, nil
+	case "trim", "trimstart", "trimend":
+		cutset := strings.Join(args, "")
+		trim := map[string]func(string, string) string{
+			"trim":      strings.Trim,
+			"trimstart": strings.TrimLeft,
+			"trimend":   strings.TrimRight,
+		}[strings.ToLower(method)]
+		if cutset == "" {
+			trim = map[string]func(string, string) string{
+				"trim":      func(s, _ string) string { return strings.TrimSpace(s) },
+				"trimstart": func(s, _ string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+				"trimend":   func(s, _ string) string { return strings.TrimRightFunc(s, unicode.IsSpace) },
+			}[strings.ToLower(method)]
+		}
+		return trim(s, cutset), nil
+	case "replace":
+		if err := argN(args, 2, method); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(s, args[0], args[1]), nil
+	case "substring":
+		r := []rune(s)
+		if len(args) < 1 || len(args) > 2 {
+			return "", errors.New("Substring requires 1 or 2 arguments")
+		}
+		start, err := toInt(args[0])
+		if err != nil || start < 0 || start > len(r) {
+			return "", fmt.Errorf("Substring: invalid start %s", args[0])
+		}
+		end := len(r)
+		if len(args) == 2 {
+			n, err := toInt(args[1])
+			if err != nil || n < 0 || start+n > len(r) {
+				return "", fmt.Errorf("Substring: invalid length %s", args[1])
+			}
+			end = start + n
+		}
+		return string(r[start:end]), nil
+	case "startswith":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		return formatBool(strings.HasPrefix(s, args[0])), nil
+	case "endswith":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		return formatBool(strings.HasSuffix(s, args[0])), nil
+	case "contains":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		return formatBool(strings.Contains(s, args[0])), nil
+	case "equals":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		return formatBool(s == args[0]), nil
+	case "indexof":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		i := strings.Index(s, args[0])
+		if i >= 0 {
+			i = len([]rune(s[:i]))
+		}
+		return strconv.Itoa(i), nil
+	case "lastindexof":
+		if err := argN(args, 1, method); err != nil {
+			return "", err
+		}
+		i := strings.LastIndex(s, args[0])
+		if i >= 0 {
+			i = len([]rune(s[:i]))
+		}
+		return strconv.Itoa(i), nil
+	case "split":
+		if len(args) < 1 {
+			return "", errors.New("Split requires separators")
+		}
+		fields := strings.FieldsFunc(s, func(r rune) bool {
+			return strings.ContainsRune(strings.Join(args, ""), r)
+		})
+		return strings.Join(fields, ";"), nil
+	case "padleft", "padright":
+		if len(args) < 1 {
+			return "", fmt.Errorf("%s requires the width", method)
+		}
+		width, err := toInt(args[0])
+		if err != nil {
+			return "", err
+		}
+		pad := " "
+		if len(args) >= 2 && args[1] != "" {
+			pad = args[1][:1]
+		}
+		n := width - len([]rune(s))
+		if n <= 0 {
+			return s, nil
+		}
+		if strings.EqualFold(method, "padleft") {
+			return strings.Repeat(pad, n) + s, nil
+		}
+		return s + strings.Repeat(pad, n), nil
+	case "insert":
+		if err := argN(args, 2, method); err != nil {
+			return "", err
+		}
+		r := []rune(s)
+		i, err := toInt(args[0])
+		if err != nil || i < 0 || i > len(r) {
+			return "", fmt.Errorf("Insert: invalid index %s", args[0])
+		}
+		return string(r[:i]) + args[1] + string(r[i:]), nil
+	case "remove":
+		r := []rune(s)
+		if len(args) < 1 {
+			return "", errors.New("Remove requires the index")
+		}
+		i, err := toInt(args[0])
+		if err != nil || i < 0 || i > len(r) {
+			return "", fmt.Errorf("Remove: invalid index %s", args[0])
+		}
+		end := len(r)
+		if len(args) >= 2 {
+			n, err := toInt(args[1])
+			if err != nil || i+n > len(r) {
+				return "", fmt.Errorf("Remove: invalid count %s", args[1])
+			}
+			end = i + n
+		}
+		return string(r[:i]) + string(r[end:]), nil
+	}
+	return "", fmt.Errorf("%s: unsupported string method", method)
+}
+
+func ensureTrailingSlash(s string) string {
+	if s != "" && !strings.HasSuffix(s, `\`) && !strings.HasSuffix(s, "/") {
+		return s + string(filepath.Separator)
+	}
+	return s
+}
+
+// fullPath makes `path` absolute. A relative path is resolved from
+// the directory of the project as MSBuild does, not from the current one.
+func (e *expander) fullPath(path string) (string, error) {
+	path = nativePath(path)
+	if !filepath.IsAbs(path) {
+		if dir, ok := e.lookup("MSBuildProjectDirectory"); ok && dir != "" {
+			path = filepath.Join(nativePath(dir), path)
+		}
+	}
+	return filepath.Abs(path)
+}
+
+// fileAbove looks for `name` from `dir` to the root directory
+// and returns the directory containing it.
+func fileAbove(dir, name string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if existsFile(filepath.Join(dir, name)) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func parseArith(s string) (float64, bool, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return float64(n), true, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, false, err
+}
+
+func arith(args []string, name string, f func(x, y float64) float64) (string, error) {
+	if err := argN(args, 2, name); err != nil {
+		return "", err
+	}
+	x, xIsInt, err := parseArith(args[0])
+	if err != nil {
+		return "", err
+	}
+	y, yIsInt, err := parseArith(args[1])
+	if err != nil {
+		return "", err
+	}
+	result := f(x, y)
+	if xIsInt && yIsInt {
+		return strconv.FormatInt(int64(result), 10), nil
+	}
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+// checkDivisor returns an error for the division of integers by zero,
+// which throws DivideByZeroException in MSBuild.
+func checkDivisor(args []string, name string) error {
+	if len(args) != 2 {
+		return nil
+	}
+	_, xIsInt, _ := parseArith(args[0])
+	if y, yIsInt, err := parseArith(args[1]); err == nil && xIsInt && yIsInt && y == 0 {
+		return fmt.Errorf("%s: divided by zero", name)
+	}
+	return nil
+}
+
+// pathRoot returns the root of the path as [System.IO.Path]::GetPathRoot:
+// `C:\` for `C:\dir`, `\` for `\dir` and "" for relative paths.
+func pathRoot(path string) string {
+	volume := filepath.VolumeName(path)
+	rest := path[len(volume):]
+	if len(volume) <= 2 && rest != "" && (rest[0] == '\\' || rest[0] == '/') {
+		return volume + rest[:1]
+	}
+	return volume
+}
+
+func bitwise(args []string, name string, f func(x, y int64) int64) (string, error) {
+	if err := argN(args, 2, name); err != nil {
+		return "", err
+	}
+	x, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+	if err != nil {
+		return "", err
+	}
+	y, err := strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(f(x, y), 10), nil
+}
+
+// parseSimpleVersion parses versions with 1 to 4 fields ignoring the suffix like "-preview"
+func parseSimpleVersion(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 4 {
+		return nil, fmt.Errorf("%s: invalid version", s)
+	}
+	result := make([]int, 4)
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: invalid version", s)
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+func versionCompare(args []string, name string, f func(int) bool) (string, error) {
+	if err := argN(args, 2, name); err != nil {
+		return "", err
+	}
+	x, err := parseSimpleVersion(args[0])
+	if err != nil {
+		return "", err
+	}
+	y, err := parseSimpleVersion(args[1])
+	if err != nil {
+		return "", err
+	}
+	return formatBool(f(compareVersion(x, y))), nil
+}
+
+func (e *expander) callMSBuild(member string, args []string) (string, error) {
+	switch strings.ToLower(member) {
+	case "add":
+		return arith(args, member, func(x, y float64) float64 { return x + y })
+	case "subtract":
+		return arith(args, member, func(x, y float64) float64 { return x - y })
+	case "multiply":
+		return arith(args, member, func(x, y float64) float64 { return x * y })
+	case "divide":
+		if err := checkDivisor(args, member); err != nil {
+			return "", err
+		}
+		return arith(args, member, func(x, y float64) float64 { return x / y })
+	case "modulo":
+		if err := checkDivisor(args, member); err != nil {
+			return "", err
+		}
+		return arith(args, member, math.Mod)
+	case "bitwiseor":
+		return bitwise(args, member, func(x, y int64) int64 { return x | y })
+	case "bitwiseand":
+		return bitwise(args, member, func(x, y int64) int64 { return x & y })
+	case "bitwisexor":
+		return bitwise(args, member, func(x, y int64) int64 { return x ^ y })
+	case "bitwisenot":
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		x, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(^x, 10), nil
+	case "valueordefault":
+		if err := argN(args, 2, member); err != nil {
+			return "", err
+		}
+		if args[0] != "" {
+			return args[0], nil
+		}
+		return args[1], nil
+	case "escape":
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		return escape(args[0]), nil
+	case "unescape":
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		return unescape(args[0]), nil
+	case "getdirectorynameoffileabove":
+		if err := argN(args, 2, member); err != nil {
+			return "", err
+		}
+		start, err := e.fullPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		return fileAbove(start, args[1]), nil
+	case "getpathoffileabove":
+		if len(args) < 1 || len(args) > 2 {
+			return "", errors.New("GetPathOfFileAbove requires 1 or 2 arguments")
+		}
+		start := ""
+		if len(args) == 2 {
+			start = args[1]
+		} else if dir, ok := e.lookup("MSBuildThisFileDirectory"); ok {
+			start = dir
+		}
+		start, err := e.fullPath(start)
+		if err != nil {
+			return "", err
+		}
+		if dir := fileAbove(start, args[0]); dir != "" {
+			return filepath.Join(dir, args[0]), nil
+		}
+		return "", nil
+	case "makerelative":
+		if err := argN(args, 2, member); err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(args[0], args[1])
+		if err != nil {
+			return args[1], nil
+		}
+		if strings.HasSuffix(args[1], `\`) || strings.HasSuffix(args[1], "/") {
+			rel = ensureTrailingSlash(rel)
+		}
+		return rel, nil
+	case "ensuretrailingslash":
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		return ensureTrailingSlash(args[0]), nil
+	case "normalizepath", "normalizedirectory":
+		path, err := e.fullPath(filepath.Join(args...))
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold(member, "normalizedirectory") {
+			path = ensureTrailingSlash(path)
+		}
+		return path, nil
+	case "isosplatform", "isosunixlike":
+		if strings.EqualFold(member, "isosunixlike") {
+			return formatBool(runtime.GOOS != "windows"), nil
+		}
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		goos := map[string]string{"windows": "windows", "linux": "linux", "osx": "darwin", "freebsd": "freebsd"}[strings.ToLower(args[0])]
+		return formatBool(goos == runtime.GOOS), nil
+	case "versionequals":
+		return versionCompare(args, member, func(c int) bool { return c == 0 })
+	case "versionnotequals":
+		return versionCompare(args, member, func(c int) bool { return c != 0 })
+	case "versiongreaterthan":
+		return versionCompare(args, member, func(c int) bool { return c > 0 })
+	case "versiongreaterthanorequals":
+		return versionCompare(args, member, func(c int) bool { return c >= 0 })
+	case "versionlessthan":
+		return versionCompare(args, member, func(c int) bool { return c < 0 })
+	case "versionlessthanorequals":
+		return versionCompare(args, member, func(c int) bool { return c <= 0 })
+	case "getregistryvalue", "getregistryvaluefromview":
+		return "", nil
+	case "isrunningfromvisualstudio":
+		return formatBool(false), nil
+	}
+	return "", fmt.Errorf("[MSBuild]::%s: unsupported function", member)
+}
+
+func (e *expander) callPath(member string, args []string) (string, error) {
+	one := func(f func(string) string) (string, error) {
+		if err := argN(args, 1, member); err != nil {
+			return "", err
+		}
+		return f(args[0]), nil
+	}
+	switch strings.ToLower(member) {
+	case "combine":
+		result := ""
+		for _, a := range args {
+			if filepath.IsAbs(a) || strings.HasPrefix(a, `\`) || strings.HasPrefix(a, "/") {
+				result = a
+			} else if result == "" {
+				result = a
+			} else {
+				result = ensureTrailingSlash(result) + a
+			}
+		}
+		return result, nil
+	case "getfilename":
+		return one(func(s string) string {
+			if i := strings.LastIndexAny(s, `\/`); i >= 0 {
+				return s[i+1:]
+			}
+			return s
+		})
+	case "getfilenamewithoutextension":
+		return one(func(s string) string {
+			if i := strings.LastIndexAny(s, `\/`); i >= 0 {
+				s = s[i+1:]
+			}
+			return strings.TrimSuffix(s, filepath.Ext(s))
+		})
+	case "getextension":
+		return one(filepath.Ext)
+	case "hasextension":
+		return one(func(s string) string { return formatBool(filepath.Ext(s) != "") })
+	case "changeextension":
+		if err := argN(args, 2, member); err != nil {
+			return "", err
+		}
+		ext := args[1]
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		return strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ext, nil
+	case "getdirectoryname":
+		return one(func(s string) string {
+			if i := strings.LastIndexAny(s, `\/`); i >= 0 {
+				return s[:i]
+			}
+			return ""
+		})
+	case "getfullpath":
+		return one(func(s string) string {
+			if full, err := e.fullPath(s); err == nil {
+				return full
+			}
+			return s
+		})
+	case "ispathrooted":
+		return one(func(s string) string {
+			return formatBool(filepath.IsAbs(s) || strings.HasPrefix(s, `\`) || strings.HasPrefix(s, "/"))
+		})
+	case "getpathroot":
+		return one(pathRoot)
+	case "directoryseparatorchar":
+		return string(filepath.Separator), nil
+	case "gettemppath":
+		return ensureTrailingSlash(os.TempDir()), nil
+	}
+	return "", fmt.Errorf("[System.IO.Path]::%s: unsupported function", member)
+}
+
+func (e *expander) callStatic(class, member string, args []string, hasArgs bool) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(class, "System."), "system.")) {
+	case "msbuild":
+		return e.callMSBuild(member, args)
+	case "io.path":
+		return e.callPath(member, args)
+	case "io.file":
+		switch strings.ToLower(member) {
+		case "exists":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			path, err := e.fullPath(args[0])
+			if err != nil {
+				return "", err
+			}
+			stat, err := os.Stat(path)
+			return formatBool(err == nil && !stat.IsDir()), nil
+		case "readalltext":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			path, err := e.fullPath(args[0])
+			if err != nil {
+				return "", err
+			}
+			bin, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return string(bin), nil
+		}
+	case "io.directory":
+		switch strings.ToLower(member) {
+		case "exists":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			path, err := e.fullPath(args[0])
+			if err != nil {
+				return "", err
+			}
+			stat, err := os.Stat(path)
+			return formatBool(err == nil && stat.IsDir()), nil
+		case "getcurrentdirectory":
+			return os.Getwd()
+		}
+	case "string":
+		switch strings.ToLower(member) {
+		case "isnullorempty":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			return formatBool(args[0] == ""), nil
+		case "isnullorwhitespace":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			return formatBool(strings.TrimSpace(args[0]) == ""), nil
+		case "copy":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			return args[0], nil
+		case "concat":
+			return strings.Join(args, ""), nil
+		case "join":
+			if len(args) < 1 {
+				return "", errors.New("Join requires the separator")
+			}
+			return strings.Join(args[1:], args[0]), nil
+		case "empty":
+			return "", nil
+		}
+	case "environment":
+		switch strings.ToLower(member) {
+		case "getenvironmentvariable":
+			if err := argN(args, 1, member); err != nil {
+				return "", err
+			}
+			return os.Getenv(args[0]), nil
+		case "newline":
+			return "\r\n", nil
+		case "processorcount":
+			return strconv.Itoa(runtime.NumCPU()), nil
+		case "machinename":
+			return os.Hostname()
+		}
+	case "version":
+		if strings.EqualFold(member, "parse") && len(args) == 1 {
+			return strings.TrimSpace(args[0]), nil
+		}
+	case "convert":
+		if strings.EqualFold(member, "toint32") && len(args) == 1 {
+			n, err := toInt(args[0])
+			return strconv.Itoa(n), err
+		}
+	}
+	return "", fmt.Errorf("[%s]::%s: unsupported function", class, member)
+}
+
+// escape converts the special characters of MSBuild into %XX
+func escape(s string) string {
+	var buffer strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("%*?@$();'", r) {
+			fmt.Fprintf(&buffer, "%%%02X", r)
+		} else {
+			buffer.WriteRune(r)
+		}
+	}
+	return buffer.String()
+}
+
+// unescape converts %XX into characters
+func unescape(s string) string {
+	var buffer strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				buffer.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		buffer.WriteByte(s[i])
+	}
+	return buffer.String()
+}
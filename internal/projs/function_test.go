@@ -0,0 +1,93 @@
+package projs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPropertyFunctions(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(sub, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Directory.Build.props"), []byte{}, 0666); err != nil {
+		t.Fatal(err)
+	}
+	properties := NewProperties(map[string]string{
+		"Platform":      "Win32",
+		"Configuration": "Release",
+		"Dir":           dir,
+		"Sub":           sub,
+		"Empty":         "",
+		"Version":       "16.11.2",
+	})
+	sep := string(filepath.Separator)
+	for _, c := range []struct {
+		text   string
+		expect string
+	}{
+		{"$(Platform)", "Win32"},
+		{"$(Platform.ToLower())", "win32"},
+		{"$(Platform.ToUpper().Replace('WIN','x'))", "x32"},
+		{"$(Configuration.Substring(0, 3))", "Rel"},
+		{"$(Configuration.Length)", "7"},
+		{"$(Configuration.StartsWith('Rel'))", "True"},
+		{"$(Version.Split('.'))", "16;11;2"},
+		{"[$(Empty.Trim())]", "[]"},
+		{"$([System.IO.Path]::Combine('bin', '$(Platform)', 'out.exe'))",
+			"bin" + sep + "Win32" + sep + "out.exe"},
+		{"$([System.IO.Path]::GetFileNameWithoutExtension('a/b/c.vcxproj'))", "c"},
+		{"$([System.IO.Path]::GetExtension('c.vcxproj'))", ".vcxproj"},
+		{"$([System.IO.Path]::GetFileName($(Sub)))", "b"},
+		{"$([MSBuild]::ValueOrDefault('$(Empty)', 'default'))", "default"},
+		{"$([MSBuild]::ValueOrDefault($(Platform), 'default'))", "Win32"},
+		{"$([MSBuild]::GetDirectoryNameOfFileAbove($(Sub), Directory.Build.props))", dir},
+		{"$([MSBuild]::GetDirectoryNameOfFileAbove($(Sub), NotFound.props))", ""},
+		{"$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(Sub)'))",
+			filepath.Join(dir, "Directory.Build.props")},
+		{"$([MSBuild]::Add(1, 2))", "3"},
+		{"$([MSBuild]::Multiply(1.5, 2))", "3"},
+		{"$([MSBuild]::Divide(7, 2))", "3"},
+		{"$([MSBuild]::Divide(1, 0))", "<error>"},
+		{"$([MSBuild]::Modulo(1, 0))", "<error>"},
+		{"$([MSBuild]::EnsureTrailingSlash('$(Dir)'))", dir + sep},
+		{"$([MSBuild]::MakeRelative($(Dir), $(Sub)))", filepath.Join("a", "b")},
+		{"$([MSBuild]::VersionGreaterThanOrEquals('$(Version)', '16.8'))", "True"},
+		{"$([MSBuild]::Escape('a;b'))", "a%3Bb"},
+		{"$([System.String]::IsNullOrEmpty('$(Empty)'))", "True"},
+		{"$([System.IO.Directory]::Exists('$(Sub)'))", "True"},
+		{"$([System.IO.File]::Exists('$(Sub)'))", "False"},
+		{"$(NotFound)", "<error>"},
+		{"$(Unclosed", "$(Unclosed"},
+	} {
+		onError := func(string) string { return "<error>" }
+		if result := properties.Expand(c.text, onError); result != c.expect {
+			t.Fatalf("%s: `%s` (expected `%s`)", c.text, result, c.expect)
+		}
+	}
+
+	// relative paths are resolved from the project directory,
+	// not from the current directory
+	properties.Set("MSBuildProjectDirectory", dir)
+	for _, c := range []struct {
+		text   string
+		expect string
+	}{
+		{"$([System.IO.Directory]::Exists('a\\b'))", "True"},
+		{"$([System.IO.File]::Exists('Directory.Build.props'))", "True"},
+		{"$([System.IO.Path]::GetFullPath('a\\b'))", sub},
+		{"$([MSBuild]::NormalizePath('a', 'b'))", sub},
+		{"$([MSBuild]::NormalizeDirectory('a'))", filepath.Join(dir, "a") + sep},
+		{"$([MSBuild]::GetDirectoryNameOfFileAbove('a\\b', Directory.Build.props))", dir},
+		{"$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', 'a'))",
+			filepath.Join(dir, "Directory.Build.props")},
+		{"$([System.IO.Path]::GetPathRoot('$(Dir)'))", filepath.VolumeName(dir) + sep},
+		{"$([System.IO.Path]::GetPathRoot('a\\b'))", ""},
+	} {
+		if result := properties.Expand(c.text, nil); result != c.expect {
+			t.Fatalf("%s: `%s` (expected `%s`)", c.text, result, c.expect)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package projs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ImportStatus tells how the Import element is handled.
+type ImportStatus int
+
+const (
+	ImportLoaded     ImportStatus = iota
+	ImportSkipped                 // the condition is false
+	ImportMissing                 // the file is not found
+	ImportDuplicated              // the file is already imported
+	ImportFailed                  // the file could not be parsed
+	ImportCycle                   // the file is being imported
+)
+
+func (s ImportStatus) String() string {
+	switch s {
+	case ImportLoaded:
+		return "loaded"
+	case ImportSkipped:
+		return "skipped"
+	case ImportMissing:
+		return "missing"
+	case ImportDuplicated:
+		return "already imported"
+	case ImportFailed:
+		return "could not be parsed"
+	case ImportCycle:
+		return "cycle"
+	}
+	return "unknown"
+}
+
+// Import is a node of the import graph. The root is the project file itself.
+type Import struct {
+	Project   string // the Project attribute as written, or the name of the implicit import
+	Path      string // the path of the file (empty when not resolved)
+	Condition string
+	Line      int // the position of the Import element in the importing file
+	Col       int
+	Status    ImportStatus
+	Imports   []*Import
+}
+
+// ErrImportCycle is returned when the files import each other.
+var ErrImportCycle = errors.New("import cycle")
+
+// addImport adds the node for the element `e` (nil for implicit imports)
+// to the file being read now.
+func (ev *evaluator) addImport(e *element, project, path string) *Import {
+	node := &Import{Project: project, Path: path}
+	if e != nil {
+		node.Condition, _ = e.attr("Condition")
+		node.Line, node.Col = e.Line, e.Col
+	}
+	if ev.node != nil {
+		ev.node.Imports = append(ev.node.Imports, node)
+	}
+	return node
+}
+
+// importFile imports the file `path` by the element `e`.
+func (ev *evaluator) importFile(e *element, project, path string) error {
+	node := ev.addImport(e, project, path)
+	err := ev.loadFile(node)
+	if err != nil && node.Status != ImportCycle {
+		fmt.Fprintf(ev.log, "Imports: `%s` could not open.\n", path)
+	}
+	return err
+}
+
+// skipImport records the Import element whose condition is false.
+func (ev *evaluator) skipImport(e *element) {
+	value, _ := e.attr("Project")
+	node := ev.addImport(e, value, "")
+	node.Status = ImportSkipped
+}
+
+// resolveImport returns the paths of the files given by the Project attribute.
+// Wildcards are expanded.
+func (ev *evaluator) resolveImport(value string) []string {
+	path := nativePath(ev.expand(value))
+	if !filepath.IsAbs(path) && ev.file != "" {
+		path = filepath.Join(filepath.Dir(ev.file), path)
+	}
+	if !hasWildcard(path) {
+		return []string{path}
+	}
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		fmt.Fprintf(ev.log, "Imports: `%s`: %s\n", path, err.Error())
+		return nil
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// cycleError makes the error telling the files importing each other.
+func (ev *evaluator) cycleError(fname string) error {
+	key := strings.ToLower(fname)
+	chain := []string{}
+	for i, f := range ev.stack {
+		if strings.ToLower(f) == key {
+			chain = append(chain, ev.stack[i:]...)
+			break
+		}
+	}
+	chain = append(chain, fname)
+	return fmt.Errorf("%w: %s", ErrImportCycle, strings.Join(chain, " -> "))
+}
+
+func (ev *evaluator) isReading(fname string) bool {
+	key := strings.ToLower(fname)
+	for _, f := range ev.stack {
+		if strings.ToLower(f) == key {
+			return true
+		}
+	}
+	return false
+}
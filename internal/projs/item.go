@@ -0,0 +1,226 @@
+package projs
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Item is an element of ItemGroup like <ClCompile Include="a.cpp" />
+type Item struct {
+	Type     string     // ex. "ClCompile"
+	Include  string     // the item specification (a file name when wildcards are used)
+	Metadata Properties // case-insensitive as the properties
+
+	recursiveDir string
+	dir          string // the base directory of the relative Include
+	definingFile string
+	project      *Project
+}
+
+var wellKnownMetadata = map[string]func(*Item) string{
+	"identity": func(i *Item) string { return i.Include },
+	"fullpath": func(i *Item) string { return i.fullPath() },
+	"rootdir": func(i *Item) string {
+		full := i.fullPath()
+		return ensureTrailingSlash(filepath.VolumeName(full) + string(filepath.Separator))
+	},
+	"filename": func(i *Item) string {
+		base := baseName(i.Include)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	},
+	"extension": func(i *Item) string { return filepath.Ext(baseName(i.Include)) },
+	"relativedir": func(i *Item) string {
+		if n := strings.LastIndexAny(i.Include, `\/`); n >= 0 {
+			return i.Include[:n+1]
+		}
+		return ""
+	},
+	"directory": func(i *Item) string {
+		full := i.fullPath()
+		dir := strings.TrimPrefix(filepath.Dir(full), filepath.VolumeName(full))
+		return ensureTrailingSlash(strings.TrimLeft(dir, `\/`))
+	},
+	"recursivedir": func(i *Item) string { return i.recursiveDir },
+	"modifiedtime": func(i *Item) string {
+		if stat, err := os.Stat(i.fullPath()); err == nil {
+			return stat.ModTime().Format("2006-01-02 15:04:05.0000000")
+		}
+		return ""
+	},
+	"definingprojectfullpath": func(i *Item) string { return i.definingFile },
+	"definingprojectdirectory": func(i *Item) string {
+		if i.definingFile == "" {
+			return ""
+		}
+		return ensureTrailingSlash(filepath.Dir(i.definingFile))
+	},
+	"definingprojectname": func(i *Item) string {
+		base := filepath.Base(i.definingFile)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	},
+	"definingprojectextension": func(i *Item) string { return filepath.Ext(i.definingFile) },
+}
+
+func baseName(s string) string {
+	if n := strings.LastIndexAny(s, `\/`); n >= 0 {
+		return s[n+1:]
+	}
+	return s
+}
+
+func (i *Item) fullPath() string {
+	p := nativePath(i.Include)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(i.dir, p)
+	}
+	if full, err := filepath.Abs(p); err == nil {
+		return full
+	}
+	return p
+}
+
+// Get returns the metadata `name`: the custom metadata of the item,
+// the default given by ItemDefinitionGroup, or the well-known metadata.
+func (i *Item) Get(name string) string {
+	if value, ok := i.lookup(name); ok {
+		return value
+	}
+	return ""
+}
+
+func (i *Item) lookup(name string) (string, bool) {
+	if value, ok := i.Metadata.Lookup(name); ok {
+		return value, true
+	}
+	if i.project != nil {
+		if value, ok := i.project.itemDefinition(i.Type, name); ok {
+			return i.expandDefinition(value), true
+		}
+	}
+	if f, ok := wellKnownMetadata[strings.ToLower(name)]; ok {
+		return f(i), true
+	}
+	return "", false
+}
+
+// MetadataNames returns the names of the custom metadata and
+// the ones given by ItemDefinitionGroup in sorted order.
+func (i *Item) MetadataNames() []string {
+	seen := map[string]struct{}{}
+	var names []string
+	add := func(m Properties) {
+		for _, name := range m.Names() {
+			if _, ok := seen[strings.ToLower(name)]; !ok {
+				seen[strings.ToLower(name)] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	add(i.Metadata)
+	if i.project != nil {
+		add(i.project.ItemDefinitions[strings.ToLower(i.Type)])
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasWildcard(s string) bool {
+	return strings.ContainsAny(s, "*?")
+}
+
+// splitSegments splits the path by both `\` and `/`.
+func splitSegments(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == '\\' || r == '/' })
+}
+
+// matchSegments matches the path segments with the pattern segments.
+// `**` matches zero or more directories.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(strings.ToLower(pattern[0]), strings.ToLower(name[0]))
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// glob returns the files matching `pattern` relative to `dir`.
+// Each result is a pair of the item specification and its %(RecursiveDir).
+func glob(dir, pattern string) [][2]string {
+	segments := splitSegments(pattern)
+	fixed := 0
+	for fixed < len(segments) && !hasWildcard(segments[fixed]) {
+		fixed++
+	}
+	// the prefix as written in the pattern (ex. `src\`)
+	prefix := ""
+	if fixed > 0 {
+		n := 0
+		for i := 0; i < fixed; i++ {
+			n = strings.Index(pattern[n:], segments[i]) + n + len(segments[i])
+		}
+		prefix = pattern[:n+1]
+	}
+	base := filepath.Join(append([]string{}, segments[:fixed]...)...)
+	if strings.HasPrefix(pattern, "/") || strings.HasPrefix(pattern, `\`) {
+		base = string(filepath.Separator) + base
+	}
+	root := base
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(dir, root)
+	}
+	rest := segments[fixed:]
+	var result [][2]string
+	filepath.Walk(root, func(fname string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, fname)
+		if err != nil {
+			return nil
+		}
+		relSegments := splitSegments(rel)
+		if !matchSegments(rest, relSegments) {
+			return nil
+		}
+		recursiveDir := ""
+		if n := len(relSegments) - 1; n > 0 && len(rest) > 1 {
+			recursiveDir = strings.Join(relSegments[:n], `\`) + `\`
+		}
+		result = append(result, [2]string{
+			prefix + strings.Join(relSegments, `\`),
+			recursiveDir,
+		})
+		return nil
+	})
+	return result
+}
+
+// matchItemSpec tests whether the item specification `spec`
+// matches `pattern` which may contain wildcards.
+func matchItemSpec(pattern, spec string) bool {
+	if !hasWildcard(pattern) {
+		return strings.EqualFold(normalizeSpec(pattern), normalizeSpec(spec))
+	}
+	return matchSegments(splitSegments(pattern), splitSegments(spec))
+}
+
+func normalizeSpec(s string) string {
+	return strings.Join(splitSegments(strings.TrimPrefix(s, `.\`)), `\`)
+}
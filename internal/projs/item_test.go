@@ -0,0 +1,166 @@
+package projs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestItems(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.cs", "sub/a.cs", "sub/deep/b.cs", "sub/c.txt", "obj/gen.cs"} {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fname, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proj := `<Project>
+  <ItemDefinitionGroup>
+    <Compile>
+      <Visible>true</Visible>
+    </Compile>
+  </ItemDefinitionGroup>
+  <ItemGroup>
+    <Compile Include="**\*.cs" Exclude="obj\**" />
+    <Compile Remove="sub\deep\b.cs" />
+    <Compile Update="main.cs">
+      <Link>Main.cs</Link>
+    </Compile>
+    <None Include="$(Name).txt" Condition="'$(Name)' != ''" />
+    <None Include="never.txt" Condition="false" />
+  </ItemGroup>
+</Project>`
+	projPath := filepath.Join(dir, "test.csproj")
+	if err := os.WriteFile(projPath, []byte(proj), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProject(NewProperties(map[string]string{"Name": "readme"}))
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	compile := p.ItemsOf("Compile")
+	if len(compile) != 2 {
+		t.Fatalf("len(Compile)=%d", len(compile))
+	}
+	var main, sub *Item
+	for _, item := range compile {
+		switch normalizeSpec(item.Include) {
+		case "main.cs":
+			main = item
+		case `sub\a.cs`:
+			sub = item
+		default:
+			t.Fatalf("unexpected item %s", item.Include)
+		}
+	}
+	if main == nil || sub == nil {
+		t.Fatal("main.cs or sub\\a.cs not found")
+	}
+	if main.Get("Link") != "Main.cs" || sub.Get("Link") != "" {
+		t.Fatal("Update failed")
+	}
+	if sub.Get("Visible") != "true" {
+		t.Fatal("ItemDefinitionGroup failed")
+	}
+	if sub.Get("RecursiveDir") != `sub\` {
+		t.Fatalf("RecursiveDir=%s", sub.Get("RecursiveDir"))
+	}
+	if sub.Get("Filename") != "a" || sub.Get("Extension") != ".cs" {
+		t.Fatal("Filename/Extension failed")
+	}
+	if sub.Get("FullPath") != filepath.Join(dir, "sub", "a.cs") {
+		t.Fatalf("FullPath=%s", sub.Get("FullPath"))
+	}
+	none := p.ItemsOf("None")
+	if len(none) != 1 || none[0].Include != "readme.txt" {
+		t.Fatal("None failed")
+	}
+}
+
+func TestItemTransforms(t *testing.T) {
+	proj := `<Project>
+  <ItemDefinitionGroup>
+    <ClCompile>
+      <ObjectFileName>obj\%(Filename).obj</ObjectFileName>
+    </ClCompile>
+  </ItemDefinitionGroup>
+  <ItemGroup>
+    <Reference Include="Foo">
+      <HintPath>lib\Foo.dll</HintPath>
+    </Reference>
+    <Reference Include="Bar" />
+    <ClCompile Include="a.cpp;b.c;a.cpp">
+      <Kind Condition="'%(Extension)'=='.cpp'">cpp</Kind>
+    </ClCompile>
+    <Source Include="@(ClCompile)" Condition="'%(Extension)'=='.c'" />
+  </ItemGroup>
+  <PropertyGroup>
+    <Hints>@(Reference->'%(HintPath)')</Hints>
+    <Refs>@(Reference, ',')</Refs>
+    <Objs>@(ClCompile->Distinct()->'%(ObjectFileName)', ' ')</Objs>
+    <CppCount>@(ClCompile->WithMetadataValue('Kind','cpp')->Count())</CppCount>
+  </PropertyGroup>
+</Project>`
+	p := NewProject(nil)
+	if err := p.Read(strings.NewReader(proj), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"Hints":    `lib\Foo.dll;`,
+		"Refs":     "Foo,Bar",
+		"Objs":     `obj\a.obj obj\b.obj`,
+		"CppCount": "2",
+	}
+	for name, value := range expect {
+		if p.Properties.Get(name) != value {
+			t.Fatalf("%s: expect `%s` but `%s`", name, value, p.Properties.Get(name))
+		}
+	}
+	source := p.ItemsOf("Source")
+	if len(source) != 1 || source[0].Include != "b.c" {
+		t.Fatalf("Source: %d items", len(source))
+	}
+}
+
+func TestMetadataIgnoreCase(t *testing.T) {
+	xml := `<Project>
+  <ItemDefinitionGroup>
+    <clcompile>
+      <Optimization>Disabled</Optimization>
+      <optimization>MaxSpeed</optimization>
+    </clcompile>
+  </ItemDefinitionGroup>
+  <ItemGroup>
+    <ClCompile Include="a.cpp">
+      <Kind>first</Kind>
+      <KIND>second</KIND>
+    </ClCompile>
+  </ItemGroup>
+</Project>`
+	p := NewProject(nil)
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	items := p.ItemsOf("ClCompile")
+	if len(items) != 1 {
+		t.Fatalf("len(ClCompile)=%d", len(items))
+	}
+	item := items[0]
+	if value := item.Get("kind"); value != "second" {
+		t.Fatalf("Kind=%s", value)
+	}
+	if value := item.Get("OPTIMIZATION"); value != "MaxSpeed" {
+		t.Fatalf("Optimization=%s", value)
+	}
+	if value := p.ItemDefinition("ClCompile", "optimization"); value != "MaxSpeed" {
+		t.Fatalf("ItemDefinition=%s", value)
+	}
+	if names := strings.Join(item.MetadataNames(), " "); names != "Kind Optimization" {
+		t.Fatalf("MetadataNames()=%s", names)
+	}
+}
@@ -0,0 +1,53 @@
+package projs
+
+import (
+	"path/filepath"
+)
+
+const dotNetDLLType = "Library"
+const nativeDLLType = "DynamicLibrary"
+
+// OutputFile returns the path of the executable built by the project.
+// A relative output path is resolved from ProjectDir (or the directory
+// of the project when ProjectDir is not given). An absolute one, such as
+// $(SolutionDir)$(Configuration)\ or the folder set in Directory.Build.props,
+// is returned as it is.
+func (p *Project) OutputFile() string {
+	props := p.Properties
+	outputFile := props.Get("OutputFile")
+	if outputFile == "" {
+		outputFile = p.ItemDefinition("Link", "OutputFile")
+	}
+	if outputFile == "" {
+		outputFile = p.ItemDefinition("Lib", "OutputFile")
+	}
+	if outputFile == "" {
+		filename := props.Get("AssemblyName")
+		if filename == "" {
+			filename = props.Get("ProjectName")
+		}
+		if ext, ok := props.Lookup("TargetExt"); ok {
+			filename += ext
+		} else if props.Get("OutputType") == dotNetDLLType {
+			filename += ".dll"
+		} else if props.Get("ConfigurationType") == nativeDLLType {
+			filename += ".dll"
+		} else {
+			filename += ".exe"
+		}
+		outdir := props.Get("OutputPath")
+		if outdir == "" {
+			outdir = props.Get("OutDir")
+		}
+		outputFile = filepath.Join(outdir, filename)
+	}
+	outputFile = nativePath(outputFile)
+	if filepath.IsAbs(outputFile) {
+		return filepath.Clean(outputFile)
+	}
+	dir, ok := props.Lookup("ProjectDir")
+	if !ok {
+		dir = props.Get("MSBuildProjectDirectory")
+	}
+	return filepath.Join(nativePath(dir), outputFile)
+}
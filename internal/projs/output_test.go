@@ -0,0 +1,70 @@
+package projs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputFile(t *testing.T) {
+	root := t.TempDir()
+	projDir := filepath.Join(root, "app")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(projDir, "app.vcxproj")
+	sep := string(filepath.Separator)
+	for outDir, expect := range map[string]string{
+		`$(SolutionDir)$(Configuration)\`: filepath.Join(root, "Release", "app.exe"),
+		`$(Configuration)\`:               filepath.Join(projDir, "Release", "app.exe"),
+	} {
+		if err := os.WriteFile(projPath, []byte(`<Project>
+  <PropertyGroup><OutDir>`+outDir+`</OutDir></PropertyGroup>
+</Project>`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p := NewProject(NewProperties(map[string]string{
+			"Configuration": "Release",
+			"ProjectName":   "app",
+			"ProjectDir":    projDir + sep,
+			"SolutionDir":   root + sep,
+		}))
+		if err := p.Load(projPath, ioutil.Discard); err != nil {
+			t.Fatal(err)
+		}
+		if output := p.OutputFile(); output != expect {
+			t.Fatalf("OutDir=%s: expect %s but %s", outDir, expect, output)
+		}
+	}
+}
+
+func TestOutputFileFromDirectoryBuildProps(t *testing.T) {
+	root := t.TempDir()
+	projDir := filepath.Join(root, "src", "app")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Directory.Build.props"),
+		[]byte(`<Project><PropertyGroup><OutDir>$(MSBuildThisFileDirectory)bin\</OutDir></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(projDir, "app.vcxproj")
+	if err := os.WriteFile(projPath, []byte(`<Project>
+  <Import Project="$(VCTargetsPath)\Microsoft.Cpp.Default.props" Condition="false" />
+</Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProject(NewProperties(map[string]string{
+		"ProjectName": "app",
+		"ProjectDir":  projDir + string(filepath.Separator),
+	}))
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	expect := filepath.Join(root, "bin", "app.exe")
+	if output := p.OutputFile(); output != expect {
+		t.Fatalf("expect %s but %s", expect, output)
+	}
+}
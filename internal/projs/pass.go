@@ -0,0 +1,86 @@
+package projs
+
+import (
+	"strings"
+)
+
+// The evaluation order of MSBuild
+// https://learn.microsoft.com/visualstudio/msbuild/build-process-overview#evaluation-phase
+//
+//   1. environment variables, global and reserved properties
+//   2. properties and imports in order (Choose/When are decided here)
+//   3. item definitions
+//   4. items
+//
+// The first pass collects ItemDefinitionGroup and ItemGroup with the
+// context where they are written, and the later passes evaluate them
+// with the final values of the properties.
+
+type deferredElement struct {
+	e      *element
+	file   string
+	guards []string
+}
+
+func (ev *evaluator) deferElement(e *element) {
+	ev.deferred = append(ev.deferred, &deferredElement{
+		e:      e,
+		file:   ev.file,
+		guards: append([]string{}, ev.guards...),
+	})
+}
+
+func (ev *evaluator) evalItemDefinitionGroup(e *element) {
+	for _, def := range e.Children {
+		if ev.condition(def) {
+			ev.evalItemDefinition(def)
+		}
+	}
+}
+
+func (ev *evaluator) evalItemGroup(e *element) {
+	for _, item := range e.Children {
+		if isBatched(item) || ev.condition(item) {
+			ev.evalItem(item)
+		}
+	}
+}
+
+// evalPass evaluates the deferred elements named `name` in order.
+func (ev *evaluator) evalPass(name string, eval func(*element)) {
+	saveFile, saveGuards := ev.file, ev.guards
+	for _, d := range ev.deferred {
+		if d.e.Name != name {
+			continue
+		}
+		ev.file, ev.guards = d.file, d.guards
+		if ev.condition(d.e) {
+			eval(d.e)
+		}
+	}
+	ev.file, ev.guards = saveFile, saveGuards
+}
+
+// expandItemLists expands @(...) left in the values of the properties.
+// MSBuild keeps them as they are and expands them where they are used.
+func (ev *evaluator) expandItemLists() {
+	props := ev.project.Properties
+	for _, name := range props.Names() {
+		value := props.Get(name)
+		if !strings.Contains(value, "@(") {
+			continue
+		}
+		expanded := ev.expand(value)
+		props.Set(name, expanded)
+		ev.recordNote(name, expanded, "item lists expanded")
+	}
+}
+
+// evalDeferred runs the passes after properties.
+func (ev *evaluator) evalDeferred() {
+	ev.evalPass("ItemDefinitionGroup", ev.evalItemDefinitionGroup)
+	ev.itemsReady = true
+	ev.evalPass("ItemGroup", ev.evalItemGroup)
+	ev.expandItemLists()
+	ev.deferred = nil
+}
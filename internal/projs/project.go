@@ -5,93 +5,507 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
 const trace = false
 
-func (properties Properties) ReadProject(r io.Reader, log io.Writer) error {
-	decoder := xml.NewDecoder(r)
-	var lastElement string
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			if err == io.EOF {
-				return nil
+// Project is the result of the evaluation of a project file.
+type Project struct {
+	Properties Properties
+	// GlobalProperties are given from outside like /p:Name=Value of MSBuild.
+	// They cannot be changed by the project files.
+	GlobalProperties Properties
+	Items            []*Item
+	// ItemDefinitions maps the item types in lower case to their default metadata
+	ItemDefinitions map[string]Properties
+	// SdksPath is the directory containing SDKs like Microsoft.NET.Sdk
+	// (ex. C:\Program Files\dotnet\sdk\8.0.100\Sdks).
+	// When empty, the conventions of Microsoft.NET.Sdk are applied instead.
+	SdksPath string
+	// Imports is the import graph whose root is the project file.
+	Imports *Import
+
+	history map[string][]*Assignment
+}
+
+// NewProject makes an empty project whose initial properties are `properties`.
+func NewProject(properties Properties) *Project {
+	if properties == nil {
+		properties = Properties{}
+	}
+	return &Project{
+		Properties:       properties,
+		GlobalProperties: Properties{},
+		ItemDefinitions:  map[string]Properties{},
+	}
+}
+
+// ItemsOf returns the items of the type `itemType`.
+func (p *Project) ItemsOf(itemType string) []*Item {
+	var result []*Item
+	for _, item := range p.Items {
+		if strings.EqualFold(item.Type, itemType) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (p *Project) itemDefinition(itemType, name string) (string, bool) {
+	return p.ItemDefinitions[strings.ToLower(itemType)].Lookup(name)
+}
+
+// Expand replaces $(property) and @(item) in `text`.
+func (p *Project) Expand(text string, onNotFound func(string) string) string {
+	e := &expander{
+		lookup: func(name string) (string, bool) {
+			return lookupProperty(p.Properties, name)
+		},
+		onNotFound: onNotFound,
+		items:      p.ItemsOf,
+	}
+	return e.expand(text)
+}
+
+// ItemDefinition returns the default metadata `name` of the item type
+// given by ItemDefinitionGroup. (ex. ItemDefinition("Link", "OutputFile"))
+func (p *Project) ItemDefinition(itemType, name string) string {
+	value, _ := p.itemDefinition(itemType, name)
+	return value
+}
+
+// nativePath converts the separators of the path written in projects
+// to the ones of the OS.
+func nativePath(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
+
+// evaluator holds the state while a project and its imports are read.
+type evaluator struct {
+	project *Project
+	log     io.Writer
+	dir     string // the directory of the root project
+	file    string // the file being read now
+	// imported is the set of the files already read
+	imported map[string]struct{}
+	// directoryBuild records whether Directory.Build.props/targets are tried
+	directoryBuild map[string]bool
+	// guards are the conditions of the elements enclosing the current one
+	guards []string
+	// node is the import graph node of the file being read now
+	node *Import
+	// stack is the files being read to detect cycles
+	stack []string
+	// err stops the evaluation
+	err error
+	// deferred are ItemDefinitionGroup and ItemGroup evaluated after properties
+	deferred []*deferredElement
+	// itemsReady enables @(...) after properties and item definitions are evaluated
+	itemsReady bool
+}
+
+func newEvaluator(p *Project, log io.Writer, dir string) *evaluator {
+	ev := &evaluator{
+		project:        p,
+		log:            log,
+		dir:            dir,
+		imported:       map[string]struct{}{},
+		directoryBuild: map[string]bool{},
+	}
+	for _, name := range p.Properties.Names() {
+		ev.recordNote(name, p.Properties.Get(name), "initial property")
+	}
+	for _, name := range p.GlobalProperties.Names() {
+		p.Properties.Set(name, p.GlobalProperties.Get(name))
+		ev.recordNote(name, p.GlobalProperties.Get(name), "global property")
+	}
+	return ev
+}
+
+func (ev *evaluator) expanderFor(item *Item, onNotFound func(string) string) *expander {
+	return &expander{
+		lookup:     ev.lookup,
+		onNotFound: onNotFound,
+		items:      ev.itemsOf(),
+		item:       item,
+	}
+}
+
+func (ev *evaluator) itemsOf() func(string) []*Item {
+	if !ev.itemsReady {
+		return nil
+	}
+	return ev.project.ItemsOf
+}
+
+// expandFor expands `text` referring `item` for %(...).
+func (ev *evaluator) expandFor(text string, item *Item) string {
+	return ev.expanderFor(item, func(s string) string {
+		fmt.Fprintf(ev.log, "$(%s) not found.\n", s)
+		return ""
+	}).expand(text)
+}
+
+func (ev *evaluator) expand(text string) string {
+	return ev.expandFor(text, nil)
+}
+
+// conditionFor evaluates the Condition attribute of `e` referring `item` for %(...).
+func (ev *evaluator) conditionFor(e *element, item *Item) bool {
+	cond, ok := e.attr("Condition")
+	if !ok {
+		return true
+	}
+	x := ev.expanderFor(item, func(s string) string {
+		fmt.Fprintf(ev.log, "Condition: variable $(%s) not found.\n", s)
+		return ""
+	})
+	status, err := evalConditionIn(cond, ev.dir, x.expand)
+	if err != nil {
+		fmt.Fprintf(ev.log, "Condition: `%s` could not parse.(%s)\n", cond, err.Error())
+		return false
+	}
+	return status
+}
+
+func (ev *evaluator) condition(e *element) bool {
+	return ev.conditionFor(e, nil)
+}
+
+// isBatched tests whether the condition of `e` refers the metadata
+// and has to be evaluated for each item.
+func isBatched(e *element) bool {
+	cond, _ := e.attr("Condition")
+	return strings.Contains(cond, "%(")
+}
+
+// evalChildren evaluates the children of <Project> or the elements like it.
+func (ev *evaluator) evalChildren(parent *element) {
+	for _, e := range parent.Children {
+		if ev.err != nil {
+			return
+		}
+		if e.Name == "Import" {
+			if isImportOf(e, importsCommonProps) {
+				ev.importDirectoryBuild("props")
+				ev.commonPropsDefaults()
 			}
-			return err
-		}
-		switch se := token.(type) {
-		case xml.StartElement:
-			if se.Name.Local == "ProjectConfiguration" {
-				target := strings.TrimSpace(properties["Configuration"]) +
-					"|" + strings.TrimSpace(properties["Platform"])
-				for _, attr1 := range se.Attr {
-					if attr1.Name.Local == "Include" && attr1.Value != target {
-						decoder.Skip()
-						// println("skip for", attr1.Value, "!=", target)
-						goto next
-					}
-				}
+			if ev.condition(e) {
+				pop := ev.pushGuard(e)
+				ev.evalImport(e)
+				pop()
+			} else {
+				ev.skipImport(e)
 			}
-			for _, attr1 := range se.Attr {
-				if attr1.Name.Local == "Condition" {
-					value := properties.Expand(attr1.Value, func(s string) string {
-						fmt.Fprintf(log, "Condition: variable $(%s) not found.\n", s)
-						return ""
-					})
-					status, err := EvalCondition(value)
-					if err != nil {
-						fmt.Fprintf(log, "Condition: `%s` could not parse.(%s)\n",
-							value, err.Error())
-						continue
-					}
-					if !status {
-						decoder.Skip()
-						goto next
-					}
-				}
+			if isImportOf(e, importsCommonTargets) {
+				ev.importDirectoryBuild("targets")
+				ev.commonTargetsDefaults()
 			}
-			if se.Name.Local == "Import" {
-				for _, attr1 := range se.Attr {
-					if attr1.Name.Local == "Project" {
-						value := properties.Expand(attr1.Value, func(s string) string {
-							fmt.Fprintf(log, "Condition: variable $(%s) not found.\n", s)
-							return ""
-						})
-						err := properties.LoadProject(value, log)
-						if err != nil {
-							fmt.Fprintf(log, "Imports: `%s` could not open.\n", value)
-						}
-					}
+			continue
+		}
+		if e.Name == "ItemGroup" || e.Name == "ItemDefinitionGroup" {
+			ev.deferElement(e)
+			continue
+		}
+		if !ev.condition(e) {
+			continue
+		}
+		switch e.Name {
+		case "PropertyGroup":
+			pop := ev.pushGuard(e)
+			for _, prop := range e.Children {
+				if ev.condition(prop) {
+					ev.setProperty(prop, ev.expand(strings.TrimSpace(prop.Text)))
+				} else {
+					ev.recordAt(prop, prop.Name, "", false, "condition is false")
 				}
 			}
-			lastElement = se.Name.Local
-			break
-		case xml.EndElement:
-			lastElement = ""
-		case xml.CharData:
-			if lastElement != "" {
-				properties[lastElement] =
-					properties.Expand(strings.TrimSpace(string(se)), func(s string) string {
-						fmt.Fprintf(log, "$(%s) not found.\n", s)
-						return ""
-					})
+			pop()
+		case "Choose":
+			ev.evalChoose(e)
+		}
+	}
+}
+
+// evalChoose evaluates the first <When> whose condition is true,
+// or <Otherwise> when none of them is true.
+func (ev *evaluator) evalChoose(choose *element) {
+	for _, e := range choose.Children {
+		switch e.Name {
+		case "When":
+			if ev.condition(e) {
+				pop := ev.pushGuard(e)
+				ev.evalChildren(e)
+				pop()
+				return
 			}
-			break
+		case "Otherwise":
+			ev.evalChildren(e)
+			return
 		}
-	next:
 	}
 }
 
-func (properties Properties) LoadProject(projname string, log io.Writer) error {
-	fd, err := os.Open(projname)
+func (ev *evaluator) evalImport(e *element) {
+	value, ok := e.attr("Project")
+	if !ok {
+		return
+	}
+	if sdk, ok := e.attr("Sdk"); ok {
+		ev.importSdkOrDefaults(splitSdks(ev.expand(sdk)), ev.expand(value))
+		return
+	}
+	paths := ev.resolveImport(value)
+	if len(paths) <= 0 {
+		fmt.Fprintf(ev.log, "Imports: `%s` matches no files.\n", value)
+	}
+	for _, path := range paths {
+		if ev.importFile(e, value, path) != nil && ev.err != nil {
+			return
+		}
+	}
+}
+
+func (ev *evaluator) evalItemDefinition(def *element) {
+	key := strings.ToLower(def.Name)
+	metadata, ok := ev.project.ItemDefinitions[key]
+	if !ok {
+		metadata = Properties{}
+		ev.project.ItemDefinitions[key] = metadata
+	}
+	for _, m := range def.Children {
+		if ev.condition(m) {
+			metadata.Set(m.Name, ev.expand(strings.TrimSpace(m.Text)))
+		}
+	}
+}
+
+var itemAttributes = map[string]struct{}{
+	"include": {}, "exclude": {}, "remove": {}, "update": {}, "condition": {},
+	"keepmetadata": {}, "removemetadata": {}, "keepduplicates": {},
+	"matchonmetadata": {}, "matchonmetadataoptions": {},
+}
+
+// setMetadata sets the metadata written in `e` to the item.
+// They may refer the metadata of the item by %(...).
+func (ev *evaluator) setMetadata(e *element, item *Item) {
+	for _, a := range e.Attr {
+		if _, ok := itemAttributes[strings.ToLower(a.Name.Local)]; !ok {
+			item.Metadata.Set(a.Name.Local, ev.expandFor(a.Value, item))
+		}
+	}
+	for _, m := range e.Children {
+		if ev.conditionFor(m, item) {
+			item.Metadata.Set(m.Name, ev.expandFor(strings.TrimSpace(m.Text), item))
+		}
+	}
+}
+
+func splitItemSpecs(s string) []string {
+	var result []string
+	for _, spec := range strings.Split(s, ";") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			result = append(result, spec)
+		}
+	}
+	return result
+}
+
+func (ev *evaluator) evalItem(e *element) {
+	batched := isBatched(e)
+	if value, ok := e.attr("Remove"); ok {
+		patterns := splitItemSpecs(ev.expand(value))
+		items := ev.project.Items[:0]
+		for _, item := range ev.project.Items {
+			if !strings.EqualFold(item.Type, e.Name) || !matchAny(patterns, item.Include) ||
+				(batched && !ev.conditionFor(e, item)) {
+				items = append(items, item)
+			}
+		}
+		ev.project.Items = items
+		return
+	}
+	if value, ok := e.attr("Update"); ok {
+		patterns := splitItemSpecs(ev.expand(value))
+		for _, item := range ev.project.Items {
+			if strings.EqualFold(item.Type, e.Name) && matchAny(patterns, item.Include) &&
+				(!batched || ev.conditionFor(e, item)) {
+				ev.setMetadata(e, item)
+			}
+		}
+		return
+	}
+	value, ok := e.attr("Include")
+	if !ok {
+		return
+	}
+	var excludes []string
+	if value, ok := e.attr("Exclude"); ok {
+		excludes = splitItemSpecs(ev.expand(value))
+	}
+	for _, spec := range splitItemSpecs(ev.expand(value)) {
+		var found [][2]string
+		if hasWildcard(spec) {
+			found = glob(ev.dir, spec)
+		} else {
+			found = [][2]string{{spec, ""}}
+		}
+		for _, f := range found {
+			if matchAny(excludes, f[0]) {
+				continue
+			}
+			item := &Item{
+				Type:         e.Name,
+				Include:      f[0],
+				Metadata:     Properties{},
+				recursiveDir: f[1],
+				dir:          ev.dir,
+				definingFile: ev.file,
+				project:      ev.project,
+			}
+			if batched && !ev.conditionFor(e, item) {
+				continue
+			}
+			ev.setMetadata(e, item)
+			ev.project.Items = append(ev.project.Items, item)
+		}
+	}
+}
+
+func matchAny(patterns []string, spec string) bool {
+	for _, p := range patterns {
+		if matchItemSpec(p, spec) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ev *evaluator) read(r io.Reader) error {
+	root, err := parseXML(r)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(log, "*** Start to read project `%s` ***\n", projname)
-	rc := properties.ReadProject(fd, log)
-	fmt.Fprintf(log, "*** End to read project `%s` ***\n", projname)
-	fd.Close()
+	return ev.evalRoot(root)
+}
+
+// evalRoot evaluates the root element of the file.
+func (ev *evaluator) evalRoot(root *element) error {
+	if ev.err != nil {
+		return ev.err
+	}
+	if sdk, ok := root.attr("Sdk"); ok {
+		ev.evalSdkProject(root, sdk)
+	} else {
+		ev.evalChildren(root)
+	}
+	return nil
+}
+
+// loadFile reads the file of the node of the import graph.
+func (ev *evaluator) loadFile(node *Import) error {
+	fname := node.Path
+	if abs, err := filepath.Abs(fname); err == nil {
+		fname = abs
+		node.Path = abs
+	}
+	if ev.isReading(fname) {
+		node.Status = ImportCycle
+		ev.err = ev.cycleError(fname)
+		return ev.err
+	}
+	key := strings.ToLower(fname)
+	if _, ok := ev.imported[key]; ok {
+		fmt.Fprintf(ev.log, "Imports: `%s` is already imported.\n", fname)
+		node.Status = ImportDuplicated
+		return nil
+	}
+	if _, err := os.Stat(fname); err != nil {
+		node.Status = ImportMissing
+		return err
+	}
+	ev.imported[key] = struct{}{}
+
+	saveFile, saveNode := ev.file, ev.node
+	ev.file, ev.node = fname, node
+	ev.stack = append(ev.stack, fname)
+	fmt.Fprintf(ev.log, "*** Start to read project `%s` ***\n", fname)
+	root, rc := loadXML(fname)
+	if rc == nil {
+		rc = ev.evalRoot(root)
+	}
+	fmt.Fprintf(ev.log, "*** End to read project `%s` ***\n", fname)
+	ev.stack = ev.stack[:len(ev.stack)-1]
+	ev.file, ev.node = saveFile, saveNode
+	if rc != nil && ev.err == nil {
+		node.Status = ImportFailed
+	}
 	return rc
 }
+
+// Read evaluates the project read from `r`. Relative paths are
+// resolved from the current directory.
+func (p *Project) Read(r io.Reader, log io.Writer) error {
+	ev := newEvaluator(p, log, "")
+	p.Imports = &Import{}
+	ev.node = p.Imports
+	if err := ev.read(r); err != nil {
+		return err
+	}
+	if ev.err != nil {
+		return ev.err
+	}
+	ev.evalDeferred()
+	return nil
+}
+
+// Load evaluates the project file `projname` and the files imported by it.
+func (p *Project) Load(projname string, log io.Writer) error {
+	ev := newEvaluator(p, log, filepath.Dir(projname))
+	ev.setProjectProperties(projname)
+	p.Imports = &Import{Project: projname, Path: projname}
+	if err := ev.loadFile(p.Imports); err != nil {
+		return err
+	}
+	if ev.err != nil {
+		return ev.err
+	}
+	ev.evalDeferred()
+	return nil
+}
+
+func (properties Properties) ReadProject(r io.Reader, log io.Writer) error {
+	return NewProject(properties).Read(r, log)
+}
+
+func (properties Properties) LoadProject(projname string, log io.Writer) error {
+	return NewProject(properties).Load(projname, log)
+}
+
+type xmlProjectConfigurations struct {
+	XMLName xml.Name `xml:"Project"`
+	Items   []struct {
+		Include string `xml:"Include,attr"`
+	} `xml:"ItemGroup>ProjectConfiguration"`
+}
+
+// ReadProjectConfigurations returns the values of
+// <ProjectConfiguration Include="Debug|Win32"> in the project file.
+// Projects without them (ex. *.csproj) return an empty slice.
+func ReadProjectConfigurations(projname string) ([]string, error) {
+	bin, err := os.ReadFile(projname)
+	if err != nil {
+		return nil, err
+	}
+	var doc xmlProjectConfigurations
+	if err := xml.Unmarshal(bin, &doc); err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		result = append(result, item.Include)
+	}
+	return result, nil
+}
@@ -1,7 +1,10 @@
 package projs
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -17,14 +20,360 @@ func TestProjectRead(t *testing.T) {
 			</PropertyGroup>
 		</Project>
 		`
-	properties := Properties(map[string]string{
+	properties := NewProperties(map[string]string{
 		"Platform": "Win32",
 	})
 	err := properties.ReadProject(strings.NewReader(xml), os.Stdout)
 	if err != nil {
 		t.Fatal()
 	}
-	if properties["Hoge"] != "Win32Hoge" {
+	if properties.Get("Hoge") != "Win32Hoge" {
 		t.Fatal()
 	}
 }
+
+func TestSdkProject(t *testing.T) {
+	dir := t.TempDir()
+	projPath := filepath.Join(dir, "App.csproj")
+	proj := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+</Project>`
+	if err := os.WriteFile(projPath, []byte(proj), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProject(NewProperties(map[string]string{"Configuration": "Release"}))
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"AssemblyName": "App",
+		"TargetExt":    ".exe",
+		"OutputPath":   `bin\Release\net8.0\`,
+	}
+	for name, value := range expect {
+		if p.Properties.Get(name) != value {
+			t.Fatalf("%s: expect `%s` but `%s`", name, value, p.Properties.Get(name))
+		}
+	}
+
+	// with the SDK directory
+	sdks := filepath.Join(dir, "sdks")
+	sdkDir := filepath.Join(sdks, "My.Sdk", "Sdk")
+	if err := os.MkdirAll(sdkDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sdkDir, "Sdk.props"), []byte(`<Project><PropertyGroup><FromProps>1</FromProps></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sdkDir, "Sdk.targets"), []byte(`<Project><PropertyGroup><OutputPath>out\$(FromProps)\</OutputPath></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projPath, []byte(`<Project Sdk="My.Sdk/1.0.0"></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p = NewProject(nil)
+	p.SdksPath = sdks
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.Properties.Get("OutputPath") != `out\1\` {
+		t.Fatalf("OutputPath=%s", p.Properties.Get("OutputPath"))
+	}
+}
+
+func TestTargetFrameworks(t *testing.T) {
+	xml := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net48;net8.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>`
+	p := NewProject(nil)
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	frameworks := p.TargetFrameworks()
+	if len(frameworks) != 2 || frameworks[0] != "net48" || frameworks[1] != "net8.0" {
+		t.Fatalf("TargetFrameworks()=%v", frameworks)
+	}
+	p = NewProject(NewProperties(map[string]string{"TargetFramework": "net48"}))
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.TargetFrameworks() != nil {
+		t.Fatal("TargetFrameworks() for the inner build must be nil")
+	}
+	if p.Properties.Get("OutputPath") != `bin\Debug\net48\` {
+		t.Fatalf("OutputPath=%s", p.Properties.Get("OutputPath"))
+	}
+}
+
+func TestDirectoryBuildProps(t *testing.T) {
+	root := t.TempDir()
+	projDir := filepath.Join(root, "src", "app")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Directory.Build.props"),
+		[]byte(`<Project><PropertyGroup><OutDir>$(MSBuildThisFileDirectory)out\</OutDir><Fromprops>1</Fromprops></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "Directory.Build.targets"),
+		[]byte(`<Project><PropertyGroup><FromTargets>$(OutDir)</FromTargets></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(projDir, "app.vcxproj")
+	if err := os.WriteFile(projPath, []byte(`<Project>
+  <Import Project="$(VCTargetsPath)\Microsoft.Cpp.Default.props" Condition="false" />
+  <PropertyGroup><OutDir>$(OutDir)bin\</OutDir></PropertyGroup>
+  <Import Project="$(VCTargetsPath)\Microsoft.Cpp.targets" />
+</Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProject(nil)
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.Properties.Get("Fromprops") != "1" {
+		t.Fatal("Directory.Build.props is not imported")
+	}
+	if !strings.HasSuffix(p.Properties.Get("FromTargets"), `out\bin\`) {
+		t.Fatalf("FromTargets=%s", p.Properties.Get("FromTargets"))
+	}
+
+	p = NewProject(NewProperties(map[string]string{"ImportDirectoryBuildProps": "false"}))
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Properties.Lookup("Fromprops"); ok {
+		t.Fatal("ImportDirectoryBuildProps=false is ignored")
+	}
+}
+
+func TestReservedProperties(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "props")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "common.props"),
+		[]byte(`<Project><PropertyGroup><ImportedDir>$(MSBuildThisFileDirectory)</ImportedDir></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(root, "app.csproj")
+	if err := os.WriteFile(projPath, []byte(`<Project>
+  <Import Project="props\common.props" />
+  <PropertyGroup>
+    <ThisDir>$(MSBuildThisFileDirectory)</ThisDir>
+    <Name>$(MSBuildProjectName)</Name>
+    <MSBuildProjectName>changed</MSBuildProjectName>
+    <FromEnv>$(VO_TEST_ENV)</FromEnv>
+  </PropertyGroup>
+</Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("VO_TEST_ENV", "env")
+	defer os.Unsetenv("VO_TEST_ENV")
+
+	p := NewProject(nil)
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"ImportedDir":             sub + string(filepath.Separator),
+		"ThisDir":                 root + string(filepath.Separator),
+		"Name":                    "app",
+		"MSBuildProjectName":      "app",
+		"MSBuildProjectDirectory": root,
+		"FromEnv":                 "env",
+	}
+	for name, value := range expect {
+		if p.Properties.Get(name) != value {
+			t.Fatalf("%s: expect `%s` but `%s`", name, value, p.Properties.Get(name))
+		}
+	}
+}
+
+func TestGlobalProperties(t *testing.T) {
+	xml := `<Project>
+  <PropertyGroup>
+    <Configuration>Debug</Configuration>
+    <OutDir>bin\$(Configuration)\</OutDir>
+  </PropertyGroup>
+</Project>`
+	p := NewProject(nil)
+	p.GlobalProperties.Set("Configuration", "Release")
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.Properties.Get("OutDir") != `bin\Release\` {
+		t.Fatalf("OutDir=%s", p.Properties.Get("OutDir"))
+	}
+}
+
+func TestHistory(t *testing.T) {
+	xml := `<Project>
+  <PropertyGroup Condition="'$(Configuration)'=='Release'">
+    <OutDir>bin\release\</OutDir>
+  </PropertyGroup>
+  <PropertyGroup>
+    <OutDir Condition="'$(Platform)'=='x64'">bin\x64\</OutDir>
+    <OutDir Condition="'$(Platform)'=='Win32'">bin\win32\</OutDir>
+  </PropertyGroup>
+</Project>`
+	p := NewProject(NewProperties(map[string]string{"Configuration": "Release", "Platform": "x64"}))
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	history := p.History("outdir")
+	if len(history) != 3 {
+		t.Fatalf("len(history)=%d", len(history))
+	}
+	if h := history[0]; h.Line != 3 || h.Col != 5 || !h.Applied ||
+		len(h.Conditions) != 1 || h.Conditions[0] != "'$(Configuration)'=='Release'" {
+		t.Fatalf("history[0]=%+v", *h)
+	}
+	if h := history[1]; h.Value != `bin\x64\` || !h.Applied || len(h.Conditions) != 1 {
+		t.Fatalf("history[1]=%+v", *h)
+	}
+	if h := history[2]; h.Applied || h.Line != 7 {
+		t.Fatalf("history[2]=%+v", *h)
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("a.part.props", `<Project><PropertyGroup><A>1</A></PropertyGroup></Project>`)
+	write("b.part.props", `<Project><PropertyGroup><B>$(A)2</B></PropertyGroup></Project>`)
+	projPath := write("app.csproj", `<Project>
+  <Import Project="*.part.props" />
+  <Import Project="never.props" Condition="false" />
+  <Import Project="missing.props" />
+</Project>`)
+	p := NewProject(nil)
+	if err := p.Load(projPath, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.Properties.Get("B") != "12" {
+		t.Fatalf("B=%s", p.Properties.Get("B"))
+	}
+	expect := []ImportStatus{ImportLoaded, ImportLoaded, ImportSkipped, ImportMissing}
+	imports := p.Imports.Imports
+	if len(imports) != len(expect) {
+		t.Fatalf("len(imports)=%d", len(imports))
+	}
+	for i, status := range expect {
+		if imports[i].Status != status {
+			t.Fatalf("imports[%d]: %s: expect %s but %s", i, imports[i].Project, status, imports[i].Status)
+		}
+	}
+
+	write("self.props", `<Project><Import Project="cycle.props" /></Project>`)
+	write("cycle.props", `<Project><Import Project="self.props" /></Project>`)
+	projPath = write("cycle.csproj", `<Project><Import Project="self.props" /></Project>`)
+	p = NewProject(nil)
+	if err := p.Load(projPath, ioutil.Discard); !errors.Is(err, ErrImportCycle) {
+		t.Fatalf("expect ErrImportCycle but %v", err)
+	}
+}
+
+func TestChoose(t *testing.T) {
+	xml := `<Project>
+  <Choose>
+    <When Condition="'$(Platform)'=='x64'">
+      <PropertyGroup><Toolset>first</Toolset></PropertyGroup>
+    </When>
+    <When Condition="'$(Platform)'!=''">
+      <PropertyGroup><Toolset>second</Toolset></PropertyGroup>
+    </When>
+    <Otherwise>
+      <PropertyGroup><Toolset>otherwise</Toolset></PropertyGroup>
+    </Otherwise>
+  </Choose>
+</Project>`
+	for platform, expect := range map[string]string{"x64": "first", "Win32": "second", "": "otherwise"} {
+		p := NewProject(NewProperties(map[string]string{"Platform": platform}))
+		if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+			t.Fatal(err)
+		}
+		if value := p.Properties.Get("Toolset"); value != expect {
+			t.Fatalf("Platform=%s: expect %s but %s", platform, expect, value)
+		}
+	}
+}
+
+func TestEvaluationPasses(t *testing.T) {
+	xml := `<Project>
+  <ItemGroup>
+    <Compile Include="$(SrcDir)main.cs" />
+  </ItemGroup>
+  <PropertyGroup>
+    <Early>[$(SrcDir)]</Early>
+    <SrcDir>src\</SrcDir>
+    <Sources>@(Compile)</Sources>
+  </PropertyGroup>
+  <ItemDefinitionGroup>
+    <Compile><Dir>$(SrcDir)</Dir></Compile>
+  </ItemDefinitionGroup>
+</Project>`
+	p := NewProject(nil)
+	if err := p.Read(strings.NewReader(xml), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if value := p.Properties.Get("Early"); value != "[]" {
+		t.Fatalf("Early=%s", value)
+	}
+	compile := p.ItemsOf("Compile")
+	if len(compile) != 1 || compile[0].Include != `src\main.cs` {
+		t.Fatal("items must be evaluated after properties")
+	}
+	if compile[0].Get("Dir") != `src\` {
+		t.Fatal("item definitions must be evaluated after properties")
+	}
+	if value := p.Properties.Get("Sources"); value != `src\main.cs` {
+		t.Fatalf("Sources=%s", value)
+	}
+}
+
+func TestConcurrentLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "common.props"),
+		[]byte(`<Project><PropertyGroup><OutDir>bin\$(Configuration)\</OutDir></PropertyGroup></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(dir, "app.vcxproj")
+	if err := os.WriteFile(projPath, []byte(`<Project><Import Project="common.props" /></Project>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []string{"Debug", "Release", "Debug", "Release"}
+	results := make([]string, len(configs))
+	done := make(chan struct{})
+	for i, config := range configs {
+		go func(i int, config string) {
+			p := NewProject(NewProperties(map[string]string{"Configuration": config}))
+			if err := p.Load(projPath, ioutil.Discard); err == nil {
+				results[i] = p.Properties.Get("OutDir")
+			}
+			done <- struct{}{}
+		}(i, config)
+	}
+	for range configs {
+		<-done
+	}
+	for i, config := range configs {
+		if results[i] != `bin\`+config+`\` {
+			t.Fatalf("%s: OutDir=%s", config, results[i])
+		}
+	}
+}
@@ -3,8 +3,7 @@ package projs
 import (
 	"errors"
 	"io"
-	"os"
-	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -42,85 +41,88 @@ func evalString(sc io.RuneScanner) (string, error) {
 	}
 }
 
-func evalEquation(sc io.RuneScanner) (bool, error) {
-	first, err := evalString(sc)
-	if err != nil {
-		return false, err
-	}
-	r, err := read1st(sc)
-	if err != nil {
-		return false, err
-	}
-	var op bool
-	if r == '=' {
-		op = true
-	} else if r == '!' {
-		op = false
-	} else {
-		return false, errors.New("1st equal-mark not found")
-	}
-	r, _, err = sc.ReadRune()
-	if err != nil {
-		return false, err
-	}
-	if r != '=' {
-		return false, errors.New("2nd equal-mark not found")
-	}
-	second, err := evalString(sc)
-	if err != nil {
-		return false, err
-	}
-	if op {
-		return first == second, nil
-	} else {
-		return first != second, nil
-	}
+type property struct {
+	Name  string
+	Value string
 }
 
-var rxExists = regexp.MustCompile(`^\s*[eE]xists\((.*)\)\s*$`)
+// Properties is a set of the MSBuild properties. The names are
+// case-insensitive and the spelling given first is kept.
+type Properties map[string]property
 
-func EvalCondition(s string) (bool, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return true, nil
+// NewProperties makes Properties from a map of names to values.
+func NewProperties(values map[string]string) Properties {
+	properties := Properties{}
+	for name, value := range values {
+		properties.Set(name, value)
 	}
-	if m := rxExists.FindStringSubmatch(s); m != nil {
-		s, err := evalString(strings.NewReader(m[1]))
-		if err != nil {
-			return false, err
-		}
-		if fd, err := os.Open(s); err == nil {
-			fd.Close()
-			return true, nil
-		} else {
-			return false, nil
-		}
+	return properties
+}
+
+// Lookup returns the value of the property `name`.
+func (properties Properties) Lookup(name string) (string, bool) {
+	p, ok := properties[strings.ToLower(name)]
+	return p.Value, ok
+}
+
+// Get returns the value of the property `name` or "" when not found.
+func (properties Properties) Get(name string) string {
+	return properties[strings.ToLower(name)].Value
+}
+
+// Set sets the value of the property `name`.
+func (properties Properties) Set(name, value string) {
+	key := strings.ToLower(name)
+	if p, ok := properties[key]; ok {
+		name = p.Name
 	}
-	return evalEquation(strings.NewReader(s))
+	properties[key] = property{Name: name, Value: value}
 }
 
-var rxEnvPattern = regexp.MustCompile(`\$\([^\)]+\)`)
+// Delete removes the property `name`.
+func (properties Properties) Delete(name string) {
+	delete(properties, strings.ToLower(name))
+}
 
-type Properties map[string]string
+// Names returns the names of the properties in sorted order.
+func (properties Properties) Names() []string {
+	names := make([]string, 0, len(properties))
+	for _, p := range properties {
+		names = append(names, p.Name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// Clone returns a copy of the properties.
+func (properties Properties) Clone() Properties {
+	c := make(Properties, len(properties))
+	for key, p := range properties {
+		c[key] = p
+	}
+	return c
+}
 
 // Expand replaces $(var) to the value of the property.
+// Property functions like $(var.ToLower()) and $([System.IO.Path]::Combine(a,b))
+// are evaluated too.
 func (properties Properties) Expand(text string, onNotFound func(string) string) string {
-	return rxEnvPattern.ReplaceAllStringFunc(text,
-		func(s string) string {
-			name := s[2 : len(s)-1]
-			if s, ok := properties[name]; ok {
-				return s
-			} else if onNotFound != nil {
-				return onNotFound(name)
-			} else {
-				return ""
-			}
-		})
+	e := &expander{
+		lookup: func(name string) (string, bool) {
+			return properties.Lookup(name)
+		},
+		onNotFound: onNotFound,
+	}
+	return e.expand(text)
 }
 
-// EvalCondition expands $(var) of text and evalute it as an equation.
+// EvalCondition evaluates the condition `text` expanding $(var) in it.
 func (properties Properties) EvalCondition(text string) (bool, error) {
-	rc, err := EvalCondition(properties.Expand(text, nil))
+	rc, err := evalConditionWith(text, func(s string) string {
+		return properties.Expand(s, nil)
+	})
 	if trace {
 		println("EvalText:", text, rc)
 	}
@@ -46,14 +46,14 @@ func TestEvalString(t *testing.T) {
 }
 
 func TestEvalPropertiesition(t *testing.T) {
-	s, err := evalEquation(strings.NewReader(" '123' == '123'"))
+	s, err := EvalCondition(" '123' == '123'")
 	if err != nil {
 		t.Fatal()
 	}
 	if !s {
 		t.Fatal()
 	}
-	s, err = evalEquation(strings.NewReader(" '123' == '124'"))
+	s, err = EvalCondition(" '123' == '124'")
 	if err != nil {
 		t.Fatal()
 	}
@@ -63,12 +63,12 @@ func TestEvalPropertiesition(t *testing.T) {
 }
 
 func TestPropertiesition(t *testing.T) {
-	properties := Properties(map[string]string{
+	properties := NewProperties(map[string]string{
 		"Platform":      "x86",
 		"Configuration": "Debug",
 	})
 
-	status, err := properties.EvalText("'$(Platform)' == 'x86'")
+	status, err := properties.EvalCondition("'$(Platform)' == 'x86'")
 	if err != nil {
 		t.Fatal()
 		return
@@ -78,7 +78,7 @@ func TestPropertiesition(t *testing.T) {
 		return
 	}
 
-	status, err = properties.EvalText("'$(Platform)' == 'Win32'")
+	status, err = properties.EvalCondition("'$(Platform)' == 'Win32'")
 	if err != nil {
 		t.Fatal()
 		return
@@ -88,3 +88,20 @@ func TestPropertiesition(t *testing.T) {
 		return
 	}
 }
+
+func TestPropertiesIgnoreCase(t *testing.T) {
+	properties := NewProperties(map[string]string{
+		"OutDir": `bin\`,
+	})
+	if properties.Expand("$(outdir)", nil) != `bin\` {
+		t.Fatal("$(outdir) does not match OutDir")
+	}
+	properties.Set("OUTDIR", `out\`)
+	if properties.Get("OutDir") != `out\` {
+		t.Fatal("Set(\"OUTDIR\") does not change OutDir")
+	}
+	names := properties.Names()
+	if len(names) != 1 || names[0] != "OutDir" {
+		t.Fatalf("Names()=%v", names)
+	}
+}
@@ -0,0 +1,75 @@
+package projs
+
+import (
+	"strings"
+)
+
+// Assignment records where and how a property is set.
+type Assignment struct {
+	Name  string
+	Value string // the expanded value
+	File  string // empty when not set by the project files
+	Line  int
+	Col   int
+	// Conditions are the ones of the element and of the enclosing
+	// PropertyGroup, When and Import elements.
+	Conditions []string
+	// Applied is false when the value is not set (ex. the condition is false).
+	Applied bool
+	// Note tells the origin or the reason (ex. "global property")
+	Note string
+}
+
+// History returns the assignments of the property `name` in evaluation order.
+func (p *Project) History(name string) []*Assignment {
+	return p.history[strings.ToLower(name)]
+}
+
+func (p *Project) record(a *Assignment) {
+	if p.history == nil {
+		p.history = map[string][]*Assignment{}
+	}
+	key := strings.ToLower(a.Name)
+	p.history[key] = append(p.history[key], a)
+}
+
+// recordAt records the assignment by the element `e` of the file being read.
+func (ev *evaluator) recordAt(e *element, name, value string, applied bool, note string) {
+	var conditions []string
+	conditions = append(conditions, ev.guards...)
+	if cond, ok := e.attr("Condition"); ok {
+		conditions = append(conditions, cond)
+	}
+	ev.project.record(&Assignment{
+		Name:       name,
+		Value:      value,
+		File:       ev.file,
+		Line:       e.Line,
+		Col:        e.Col,
+		Conditions: conditions,
+		Applied:    applied,
+		Note:       note,
+	})
+}
+
+// recordNote records the assignment not written in the files.
+func (ev *evaluator) recordNote(name, value, note string) {
+	ev.project.record(&Assignment{
+		Name:    name,
+		Value:   value,
+		Applied: true,
+		Note:    note,
+	})
+}
+
+// pushGuard adds the condition of `e` to the ones enclosing the elements
+// evaluated next and returns the function to remove it.
+func (ev *evaluator) pushGuard(e *element) func() {
+	cond, ok := e.attr("Condition")
+	if !ok {
+		return func() {}
+	}
+	ev.guards = append(ev.guards, cond)
+	n := len(ev.guards) - 1
+	return func() { ev.guards = ev.guards[:n] }
+}
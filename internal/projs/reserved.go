@@ -0,0 +1,152 @@
+package projs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Reserved and well-known properties
+// https://learn.microsoft.com/visualstudio/msbuild/msbuild-reserved-and-well-known-properties
+
+// reservedProperties cannot be changed by the projects.
+var reservedProperties = map[string]struct{}{
+	"msbuildprojectdirectory":        {},
+	"msbuildprojectdirectorynoroot":  {},
+	"msbuildprojectextension":        {},
+	"msbuildprojectfile":             {},
+	"msbuildprojectfullpath":         {},
+	"msbuildprojectname":             {},
+	"msbuildstartupdirectory":        {},
+	"msbuildthisfile":                {},
+	"msbuildthisfiledirectory":       {},
+	"msbuildthisfiledirectorynoroot": {},
+	"msbuildthisfileextension":       {},
+	"msbuildthisfilefullpath":        {},
+	"msbuildthisfilename":            {},
+}
+
+func isReserved(name string) bool {
+	_, ok := reservedProperties[strings.ToLower(name)]
+	return ok
+}
+
+// withoutRoot removes the drive and the leading separator of the path.
+func withoutRoot(path string) string {
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	return strings.TrimLeft(path, `\/`)
+}
+
+// setProjectProperties sets the reserved properties of the root project file.
+func (ev *evaluator) setProjectProperties(projname string) {
+	full, err := filepath.Abs(projname)
+	if err != nil {
+		full = projname
+	}
+	props := ev.project.Properties
+	dir := filepath.Dir(full)
+	base := filepath.Base(full)
+	ext := filepath.Ext(base)
+	defer func() {
+		for _, name := range props.Names() {
+			if isReserved(name) {
+				ev.recordNote(name, props.Get(name), "reserved property")
+			}
+		}
+	}()
+	props.Set("MSBuildProjectFullPath", full)
+	props.Set("MSBuildProjectDirectory", dir)
+	props.Set("MSBuildProjectDirectoryNoRoot", withoutRoot(dir))
+	props.Set("MSBuildProjectFile", base)
+	props.Set("MSBuildProjectName", strings.TrimSuffix(base, ext))
+	props.Set("MSBuildProjectExtension", ext)
+	if wd, err := os.Getwd(); err == nil {
+		props.Set("MSBuildStartupDirectory", wd)
+	}
+}
+
+// thisFileProperty returns MSBuildThisFile* of the file being read now.
+func (ev *evaluator) thisFileProperty(name string) (string, bool) {
+	if ev.file == "" {
+		return "", false
+	}
+	base := filepath.Base(ev.file)
+	switch strings.ToLower(name) {
+	case "msbuildthisfile":
+		return base, true
+	case "msbuildthisfiledirectory":
+		return addSeparator(filepath.Dir(ev.file)), true
+	case "msbuildthisfiledirectorynoroot":
+		return addSeparator(withoutRoot(filepath.Dir(ev.file))), true
+	case "msbuildthisfileextension":
+		return filepath.Ext(base), true
+	case "msbuildthisfilefullpath":
+		return ev.file, true
+	case "msbuildthisfilename":
+		return strings.TrimSuffix(base, filepath.Ext(base)), true
+	}
+	return "", false
+}
+
+func addSeparator(dir string) string {
+	if dir == "" {
+		return dir
+	}
+	return ensureTrailingSlash(dir)
+}
+
+// lookupProperty returns the property or the environment variable.
+func lookupProperty(properties Properties, name string) (string, bool) {
+	if value, ok := properties.Lookup(name); ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+func (ev *evaluator) lookup(name string) (string, bool) {
+	if value, ok := ev.thisFileProperty(name); ok {
+		return value, true
+	}
+	return lookupProperty(ev.project.Properties, name)
+}
+
+// setProperty sets the property written as the element `e` in PropertyGroup.
+// The reserved properties and the global properties are not changed.
+func (ev *evaluator) setProperty(e *element, value string) {
+	name := e.Name
+	if isReserved(name) {
+		fmt.Fprintf(ev.log, "Property: `%s` is reserved.\n", name)
+		ev.recordAt(e, name, value, false, "reserved property")
+		return
+	}
+	if _, ok := ev.project.GlobalProperties.Lookup(name); ok {
+		fmt.Fprintf(ev.log, "Property: `%s` is a global property.\n", name)
+		ev.recordAt(e, name, value, false, "global property")
+		return
+	}
+	ev.project.Properties.Set(name, value)
+	ev.recordAt(e, name, value, true, "")
+}
+
+// commonPropsDefaults sets the properties defined by
+// Microsoft.Common.props and Microsoft.Cpp.Default.props.
+func (ev *evaluator) commonPropsDefaults() {
+	props := ev.project.Properties
+	ev.setDefault("ProjectName", props.Get("MSBuildProjectName"))
+	ev.setDefault("TargetName", props.Get("ProjectName"))
+}
+
+// commonTargetsDefaults sets the properties defined by
+// Microsoft.Common.targets after the project.
+func (ev *evaluator) commonTargetsDefaults() {
+	props := ev.project.Properties
+	if props.Get("AssemblyName") != "" {
+		ev.setDefault("TargetName", props.Get("AssemblyName"))
+	} else {
+		ev.setDefault("TargetName", props.Get("MSBuildProjectName"))
+	}
+	if ext := props.Get("TargetExt"); ext != "" {
+		ev.setDefault("TargetFileName", props.Get("TargetName")+ext)
+	}
+}
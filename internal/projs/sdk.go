@@ -0,0 +1,155 @@
+package projs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SDK-style projects
+// https://learn.microsoft.com/visualstudio/msbuild/how-to-use-project-sdk
+//
+//   <Project Sdk="Microsoft.NET.Sdk"> is same as
+//
+//   <Project>
+//     <Import Project="Sdk.props" Sdk="Microsoft.NET.Sdk" />
+//     ...
+//     <Import Project="Sdk.targets" Sdk="Microsoft.NET.Sdk" />
+//   </Project>
+
+// splitSdks splits the Sdk attribute like "Microsoft.NET.Sdk;Name/1.0.0"
+// and removes the versions.
+func splitSdks(value string) []string {
+	var result []string
+	for _, sdk := range strings.Split(value, ";") {
+		if name, _, _ := strings.Cut(sdk, "/"); strings.TrimSpace(name) != "" {
+			result = append(result, strings.TrimSpace(name))
+		}
+	}
+	return result
+}
+
+// sdkFile returns the path of `fname` (ex. Sdk.props) of the SDK `sdk`.
+func (ev *evaluator) sdkFile(sdk, fname string) (string, bool) {
+	if ev.project.SdksPath == "" {
+		return "", false
+	}
+	path := filepath.Join(ev.project.SdksPath, sdk, "Sdk", fname)
+	if _, err := os.Stat(path); err != nil {
+		return path, false
+	}
+	return path, true
+}
+
+// importSdk imports `fname` of the SDKs and reports whether all of them are found.
+func (ev *evaluator) importSdk(sdks []string, fname string) bool {
+	found := true
+	for _, sdk := range sdks {
+		path, ok := ev.sdkFile(sdk, fname)
+		if !ok {
+			fmt.Fprintf(ev.log, "Sdk: %s of `%s` not found in `%s`.\n", fname, sdk, ev.project.SdksPath)
+			ev.addImport(nil, fname+" (Sdk="+sdk+")", path).Status = ImportMissing
+			found = false
+			continue
+		}
+		if err := ev.importFile(nil, fname+" (Sdk="+sdk+")", path); err != nil {
+			found = false
+		}
+	}
+	return found
+}
+
+// addBackslash is same as ensureTrailingSlash, but always uses `\`
+// as the projects do.
+func addBackslash(s string) string {
+	if s != "" && !strings.HasSuffix(s, `\`) && !strings.HasSuffix(s, "/") {
+		return s + `\`
+	}
+	return s
+}
+
+func (ev *evaluator) setDefault(name, value string) {
+	if ev.project.Properties.Get(name) == "" {
+		if _, ok := ev.project.GlobalProperties.Lookup(name); !ok {
+			ev.project.Properties.Set(name, value)
+			ev.recordNote(name, value, "default value")
+		}
+	}
+}
+
+// sdkPropsDefaults sets the properties that the SDK defines before the project.
+func (ev *evaluator) sdkPropsDefaults() {
+	ev.setDefault("Configuration", "Debug")
+	ev.setDefault("Platform", "AnyCPU")
+	ev.setDefault("BaseOutputPath", `bin\`)
+	ev.setDefault("BaseIntermediateOutputPath", `obj\`)
+	ev.setDefault("UsingMicrosoftNETSdk", "true")
+}
+
+// sdkTargetsDefaults applies the conventions of the SDK after the project
+// when Sdk.targets is not available.
+func (ev *evaluator) sdkTargetsDefaults() {
+	props := ev.project.Properties
+	ev.setDefault("AssemblyName", props.Get("MSBuildProjectName"))
+	ev.setDefault("OutputType", "Library")
+	switch strings.ToLower(props.Get("OutputType")) {
+	case "exe", "winexe":
+		ev.setDefault("TargetExt", ".exe")
+	default:
+		ev.setDefault("TargetExt", ".dll")
+	}
+	if props.Get("OutputPath") == "" {
+		outputPath := addBackslash(props.Get("BaseOutputPath"))
+		if platform := props.Get("Platform"); platform != "" && !strings.EqualFold(platform, "AnyCPU") {
+			outputPath += addBackslash(platform)
+		}
+		outputPath += addBackslash(props.Get("Configuration"))
+		if tf := props.Get("TargetFramework"); tf != "" && !strings.EqualFold(props.Get("AppendTargetFrameworkToOutputPath"), "false") {
+			outputPath += addBackslash(tf)
+		}
+		if rid := props.Get("RuntimeIdentifier"); rid != "" && !strings.EqualFold(props.Get("AppendRuntimeIdentifierToOutputPath"), "false") {
+			outputPath += addBackslash(rid)
+		}
+		ev.setDefault("OutputPath", outputPath)
+	}
+	ev.setDefault("OutDir", props.Get("OutputPath"))
+	ev.commonTargetsDefaults()
+}
+
+// importSdkOrDefaults imports `fname` of the SDKs. When not found,
+// the defaults for Sdk.props or Sdk.targets are applied instead.
+func (ev *evaluator) importSdkOrDefaults(sdks []string, fname string) {
+	if ev.importSdk(sdks, fname) {
+		return
+	}
+	switch strings.ToLower(fname) {
+	case "sdk.props":
+		ev.importDirectoryBuild("props")
+		ev.commonPropsDefaults()
+		ev.sdkPropsDefaults()
+	case "sdk.targets":
+		ev.importDirectoryBuild("targets")
+		ev.sdkTargetsDefaults()
+	}
+}
+
+// evalSdkProject evaluates the root element which has the Sdk attribute.
+func (ev *evaluator) evalSdkProject(root *element, value string) {
+	sdks := splitSdks(value)
+	ev.importSdkOrDefaults(sdks, "Sdk.props")
+	ev.importDirectoryBuild("props")
+	ev.evalChildren(root)
+	ev.importSdkOrDefaults(sdks, "Sdk.targets")
+	ev.importDirectoryBuild("targets")
+}
+
+// TargetFrameworks returns the frameworks of the multi-targeting project
+// (ex. <TargetFrameworks>net48;net8.0</TargetFrameworks>). When the project
+// is evaluated for one of them (TargetFramework is set), it returns nil.
+func (p *Project) TargetFrameworks() []string {
+	if p.Properties.Get("TargetFramework") != "" {
+		return nil
+	}
+	return splitItemSpecs(p.Properties.Get("TargetFrameworks"))
+}
@@ -0,0 +1,199 @@
+package projs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Item lists and metadata in expressions
+// https://learn.microsoft.com/visualstudio/msbuild/item-functions
+//
+//   @(Type)
+//   @(Type, 'separator')
+//   @(Type->'%(Filename).obj')
+//   @(Type->Distinct()->WithMetadataValue('Name','Value'), 'separator')
+//   %(Name) and %(Type.Name)
+
+// readQuoted reads 'string' at the top of `s` and returns
+// its content and the rest of `s`.
+func readQuoted(s string) (string, string, bool) {
+	if s == "" {
+		return "", s, false
+	}
+	q := s[0]
+	if q != '\'' && q != '"' && q != '`' {
+		return "", s, false
+	}
+	end := strings.IndexByte(s[1:], q)
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1 : end+1], s[end+2:], true
+}
+
+// readItemName reads the name of the item type or the item function.
+// Unlike readIdent, `-` is not included for `->`.
+func readItemName(s string) (string, string) {
+	for i, r := range s {
+		if r == '-' || !isIdentRune(r) {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+func (i *Item) withInclude(include string) *Item {
+	c := *i
+	c.Include = include
+	return &c
+}
+
+func scalarItem(value string) []*Item {
+	return []*Item{{Include: value, Metadata: Properties{}}}
+}
+
+func (e *expander) callItemFunction(items []*Item, name string, args []string) ([]*Item, bool) {
+	arg := func(n int) string {
+		if n < len(args) {
+			return args[n]
+		}
+		return ""
+	}
+	var result []*Item
+	switch strings.ToLower(name) {
+	case "distinct", "distinctwithcase":
+		seen := map[string]struct{}{}
+		for _, item := range items {
+			key := item.Include
+			if strings.EqualFold(name, "distinct") {
+				key = strings.ToLower(key)
+			}
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				result = append(result, item)
+			}
+		}
+	case "reverse":
+		for i := len(items) - 1; i >= 0; i-- {
+			result = append(result, items[i])
+		}
+	case "count":
+		result = scalarItem(strconv.Itoa(len(items)))
+	case "withmetadatavalue":
+		for _, item := range items {
+			if strings.EqualFold(item.Get(arg(0)), arg(1)) {
+				result = append(result, item)
+			}
+		}
+	case "hasmetadata":
+		for _, item := range items {
+			if item.Get(arg(0)) != "" {
+				result = append(result, item)
+			}
+		}
+	case "anyhavemetadatavalue":
+		found := false
+		for _, item := range items {
+			if strings.EqualFold(item.Get(arg(0)), arg(1)) {
+				found = true
+				break
+			}
+		}
+		result = scalarItem(formatBool(found))
+	case "metadata":
+		for _, item := range items {
+			if value := item.Get(arg(0)); value != "" {
+				result = append(result, item.withInclude(value))
+			}
+		}
+	case "clearmetadata":
+		for _, item := range items {
+			c := item.withInclude(item.Include)
+			c.Metadata = Properties{}
+			c.project = nil
+			result = append(result, c)
+		}
+	default:
+		return nil, false
+	}
+	return result, true
+}
+
+// evalItemList evaluates the inside of @(...)
+func (e *expander) evalItemList(body string) string {
+	itemType, rest := readItemName(strings.TrimSpace(body))
+	items := e.items(itemType)
+	separator := ";"
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+		if strings.HasPrefix(rest, ",") {
+			separator = e.evalArg(rest[1:])
+			break
+		}
+		if !strings.HasPrefix(rest, "->") {
+			return e.notFound("@(" + body + ")")
+		}
+		rest = strings.TrimSpace(rest[2:])
+		if pattern, next, ok := readQuoted(rest); ok {
+			transformed := make([]*Item, 0, len(items))
+			for _, item := range items {
+				sub := *e
+				sub.item = item
+				transformed = append(transformed, item.withInclude(sub.expand(pattern)))
+			}
+			items = transformed
+			rest = next
+			continue
+		}
+		var name string
+		name, rest = readItemName(rest)
+		var args []string
+		if strings.HasPrefix(rest, "(") {
+			var err error
+			args, rest, err = e.readArgs(rest)
+			if err != nil {
+				return e.notFound("@(" + body + ")")
+			}
+		}
+		var ok bool
+		items, ok = e.callItemFunction(items, name, args)
+		if !ok {
+			return e.notFound("@(" + body + ")")
+		}
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Include)
+	}
+	return strings.Join(values, separator)
+}
+
+// metadataRef evaluates the inside of %(...)
+func (i *Item) metadataRef(body string) string {
+	body = strings.TrimSpace(body)
+	if itemType, name, ok := strings.Cut(body, "."); ok {
+		if !strings.EqualFold(itemType, i.Type) {
+			return ""
+		}
+		body = name
+	}
+	return i.Get(body)
+}
+
+// expandDefinition replaces %(...) in the default metadata given by
+// ItemDefinitionGroup with the metadata of the item.
+func (i *Item) expandDefinition(value string) string {
+	if !strings.Contains(value, "%(") {
+		return value
+	}
+	c := *i
+	c.project = nil
+	e := &expander{
+		lookup: func(string) (string, bool) { return "", false },
+		item:   &c,
+	}
+	return e.expand(value)
+}
@@ -0,0 +1,90 @@
+package projs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// element is a node of the parsed project file.
+type element struct {
+	Name     string
+	Attr     []xml.Attr
+	Children []*element
+	Text     string
+	Offset   int64 // byte offset of the start tag in the file
+	Line     int   // 1-based line number of the start tag
+	Col      int   // 1-based column number of the start tag
+}
+
+// attr returns the value of the attribute `name` (case-insensitive).
+func (e *element) attr(name string) (string, bool) {
+	for _, a := range e.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseXML reads the XML document and returns the root element.
+func parseXML(r io.Reader) (*element, error) {
+	bin, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(bin))
+	var stack []*element
+	var root *element
+
+	// the position of `offset` counted from the previous one
+	line, lineStart, counted := 1, 0, 0
+	position := func(offset int) (int, int) {
+		for ; counted < offset && counted < len(bin); counted++ {
+			if bin[counted] == '\n' {
+				line++
+				lineStart = counted + 1
+			}
+		}
+		return line, utf8.RuneCount(bin[lineStart:offset]) + 1
+	}
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			if root == nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &element{
+				Name:   t.Name.Local,
+				Attr:   append([]xml.Attr{}, t.Attr...),
+				Offset: offset,
+			}
+			e.Line, e.Col = position(int(offset))
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+}
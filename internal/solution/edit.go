@@ -0,0 +1,331 @@
+package solution
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the order of GlobalSections which Visual Studio writes
+var globalSectionOrder = []string{
+	"SolutionConfigurationPlatforms",
+	"ProjectConfigurationPlatforms",
+	"SolutionProperties",
+	"NestedProjects",
+	"ExtensibilityGlobals",
+}
+
+func sectionRank(name string) int {
+	for i, s := range globalSectionOrder {
+		if s == name {
+			return i
+		}
+	}
+	return len(globalSectionOrder)
+}
+
+// globalSection returns the GlobalSection named `name`
+// and creates it at the place Visual Studio does when it does not exist.
+func (sln *Solution) globalSection(name, timing string) *Section {
+	if s := sln.GlobalSection(name); s != nil {
+		return s
+	}
+	s := &Section{Name: name, Timing: timing}
+	rank := sectionRank(name)
+	for i, s1 := range sln.GlobalSections {
+		if sectionRank(s1.Name) > rank {
+			sln.GlobalSections = append(sln.GlobalSections[:i],
+				append([]*Section{s}, sln.GlobalSections[i:]...)...)
+			return s
+		}
+	}
+	sln.GlobalSections = append(sln.GlobalSections, s)
+	return s
+}
+
+// ParentGUID returns the GUID of the solution folder containing
+// the project `guid`, or an empty string for the top level.
+func (sln *Solution) ParentGUID(guid string) string {
+	if nested := sln.GlobalSection("NestedProjects"); nested != nil {
+		for _, e := range nested.Entries {
+			if strings.EqualFold(e.Key, guid) {
+				return e.Value
+			}
+		}
+	}
+	return ""
+}
+
+// Children returns the projects and the folders directly in the solution
+// folder `parentGUID` (empty for the top level) in the order of the file.
+// Projects whose parent is unknown are treated as top level ones.
+func (sln *Solution) Children(parentGUID string) []*Project {
+	var result []*Project
+	for _, p := range sln.Projects {
+		parent := sln.ParentGUID(p.GUID)
+		if parent != "" && sln.FindProject(parent) == nil {
+			parent = ""
+		}
+		if strings.EqualFold(parent, parentGUID) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (sln *Solution) setParent(guid, parentGUID string) {
+	nested := sln.globalSection("NestedProjects", "preSolution")
+	for _, e := range nested.Entries {
+		if strings.EqualFold(e.Key, guid) {
+			e.Value = parentGUID
+			return
+		}
+	}
+	nested.Entries = append(nested.Entries, &Entry{Key: guid, Value: parentGUID})
+}
+
+// AddFolder returns the solution folder `folder` (ex. "src/libs"),
+// creating it and its parents when they do not exist.
+func (sln *Solution) AddFolder(folder string) *Project {
+	var parent *Project
+	for _, name := range strings.FieldsFunc(folder, func(r rune) bool { return r == '/' || r == '\\' }) {
+		var found *Project
+		for _, p := range sln.Projects {
+			if !p.IsFolder() || !strings.EqualFold(p.Name, name) {
+				continue
+			}
+			parentGUID := sln.ParentGUID(p.GUID)
+			if (parent == nil && parentGUID == "") ||
+				(parent != nil && strings.EqualFold(parent.GUID, parentGUID)) {
+				found = p
+				break
+			}
+		}
+		if found == nil {
+			found = &Project{
+				TypeGUID: SolutionFolderGUID,
+				Name:     name,
+				Path:     name,
+				GUID:     newGUID(),
+				Configs:  map[string]*ProjectConfig{},
+			}
+			sln.Projects = append(sln.Projects, found)
+			if parent != nil {
+				sln.setParent(found.GUID, parent.GUID)
+			}
+		}
+		parent = found
+	}
+	sln.index()
+	return parent
+}
+
+type xmlProjectInfo struct {
+	XMLName       xml.Name `xml:"Project"`
+	ProjectGuid   []string `xml:"PropertyGroup>ProjectGuid"`
+	Configuration []struct {
+		Include string `xml:"Include,attr"`
+	} `xml:"ItemGroup>ProjectConfiguration"`
+}
+
+func normalizePlatform(p string) string {
+	p = strings.ToLower(strings.ReplaceAll(p, " ", ""))
+	if p == "win32" {
+		return "x86"
+	}
+	return p
+}
+
+// mapConfig selects the project configuration for the solution one.
+func mapConfig(slnConfig string, projConfigs []string, defaultPlatform string) string {
+	build, platform, _ := strings.Cut(slnConfig, "|")
+	if len(projConfigs) <= 0 {
+		if defaultPlatform == "" {
+			return slnConfig
+		}
+		return build + "|" + defaultPlatform
+	}
+	var sameBuild string
+	for _, c := range projConfigs {
+		b, p, _ := strings.Cut(c, "|")
+		if !strings.EqualFold(b, build) {
+			continue
+		}
+		if normalizePlatform(p) == normalizePlatform(platform) {
+			return c
+		}
+		if sameBuild == "" {
+			sameBuild = c
+		}
+	}
+	if sameBuild != "" {
+		return sameBuild
+	}
+	return projConfigs[0]
+}
+
+// RelPath returns the path of `fname` relative to the solution directory
+// written in the style of *.sln.
+func (sln *Solution) RelPath(fname string) (string, error) {
+	absSln, err := filepath.Abs(sln.Path)
+	if err != nil {
+		return "", err
+	}
+	absProj, err := filepath.Abs(fname)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(filepath.Dir(absSln), absProj)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(rel, "/", `\`), nil
+}
+
+// AddProject registers the project file `fname` into the solution folder
+// `folder` (empty for the top level) with the mappings for all solution
+// configurations.
+func (sln *Solution) AddProject(fname, folder string) (*Project, error) {
+	relPath, err := sln.RelPath(fname)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range sln.Projects {
+		if strings.EqualFold(p.Path, relPath) {
+			return nil, fmt.Errorf("%s: already registered", relPath)
+		}
+	}
+	bin, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var info xmlProjectInfo
+	if err := xml.Unmarshal(bin, &info); err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	typeGUID := TypeGUIDOf(fname)
+	if typeGUID == "" {
+		return nil, fmt.Errorf("%s: unknown project type", fname)
+	}
+	guid := ""
+	for _, g := range info.ProjectGuid {
+		if g = strings.TrimSpace(g); g != "" {
+			guid = strings.ToUpper(g)
+		}
+	}
+	if guid == "" || sln.FindProject(guid) != nil {
+		guid = newGUID()
+	}
+	var projConfigs []string
+	for _, c := range info.Configuration {
+		projConfigs = append(projConfigs, c.Include)
+	}
+	defaultPlatform := "Any CPU"
+	if typeGUID == CppGUID {
+		defaultPlatform = ""
+	}
+
+	slnConfigs := sln.globalSection("SolutionConfigurationPlatforms", "preSolution")
+	if len(slnConfigs.Entries) <= 0 {
+		if len(projConfigs) > 0 {
+			for _, c := range projConfigs {
+				b, p, _ := strings.Cut(c, "|")
+				if strings.EqualFold(p, "Win32") {
+					p = "x86"
+				}
+				slnConfigs.Entries = append(slnConfigs.Entries, &Entry{Key: b + "|" + p, Value: b + "|" + p})
+			}
+		} else {
+			for _, c := range []string{"Debug|Any CPU", "Release|Any CPU"} {
+				slnConfigs.Entries = append(slnConfigs.Entries, &Entry{Key: c, Value: c})
+			}
+		}
+	}
+	mapping := sln.globalSection("ProjectConfigurationPlatforms", "postSolution")
+	for _, e := range slnConfigs.Entries {
+		active := mapConfig(e.Key, projConfigs, defaultPlatform)
+		mapping.Entries = append(mapping.Entries,
+			&Entry{Key: guid + "." + e.Key + ".ActiveCfg", Value: active},
+			&Entry{Key: guid + "." + e.Key + ".Build.0", Value: active})
+	}
+
+	proj := &Project{
+		TypeGUID: typeGUID,
+		Name:     strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname)),
+		Path:     relPath,
+		GUID:     guid,
+	}
+	sln.Projects = append(sln.Projects, proj)
+	if folder != "" {
+		sln.setParent(guid, sln.AddFolder(folder).GUID)
+	}
+	sln.index()
+	return proj, nil
+}
+
+// RemoveProject drops the project with its configuration mappings,
+// its nesting and the dependencies on it. The contents of a removed
+// solution folder are moved to its parent.
+func (sln *Solution) RemoveProject(proj *Project) {
+	projects := sln.Projects[:0]
+	for _, p := range sln.Projects {
+		if p != proj {
+			projects = append(projects, p)
+		}
+		if deps := p.Section("ProjectDependencies"); deps != nil {
+			deps.Entries = removeEntries(deps.Entries, func(e *Entry) bool {
+				return strings.EqualFold(e.Key, proj.GUID)
+			})
+			if len(deps.Entries) <= 0 {
+				sections := p.Sections[:0]
+				for _, s := range p.Sections {
+					if s != deps {
+						sections = append(sections, s)
+					}
+				}
+				p.Sections = sections
+			}
+		}
+	}
+	sln.Projects = projects
+
+	if mapping := sln.GlobalSection("ProjectConfigurationPlatforms"); mapping != nil {
+		prefix := strings.ToUpper(proj.GUID) + "."
+		mapping.Entries = removeEntries(mapping.Entries, func(e *Entry) bool {
+			return strings.HasPrefix(strings.ToUpper(e.Key), prefix)
+		})
+	}
+	if nested := sln.GlobalSection("NestedProjects"); nested != nil {
+		parentGUID := sln.ParentGUID(proj.GUID)
+		nested.Entries = removeEntries(nested.Entries, func(e *Entry) bool {
+			if strings.EqualFold(e.Value, proj.GUID) && parentGUID != "" {
+				e.Value = parentGUID
+				return false
+			}
+			return strings.EqualFold(e.Key, proj.GUID) ||
+				strings.EqualFold(e.Value, proj.GUID)
+		})
+		if len(nested.Entries) <= 0 {
+			sections := sln.GlobalSections[:0]
+			for _, s := range sln.GlobalSections {
+				if s != nested {
+					sections = append(sections, s)
+				}
+			}
+			sln.GlobalSections = sections
+		}
+	}
+	sln.index()
+}
+
+func removeEntries(entries []*Entry, f func(*Entry) bool) []*Entry {
+	result := entries[:0]
+	for _, e := range entries {
+		if !f(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
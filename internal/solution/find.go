@@ -0,0 +1,80 @@
+package solution
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultIgnores are the patterns of directories not searched by FindRecursive.
+var DefaultIgnores = []string{"bin/", "obj/", ".git/", ".vs/"}
+
+// matchIgnore tests the path `rel` (relative to the root, separated by '/')
+// with the patterns like .gitignore: "bin/" matches directories named bin,
+// "*.bak" matches files and directories, and "src/old/" matches the path.
+func matchIgnore(patterns []string, rel string, isDir bool) bool {
+	base := path.Base(rel)
+	for _, pattern := range patterns {
+		p := strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if p != filepath.ToSlash(pattern) && !isDir {
+			continue
+		}
+		target := base
+		if strings.Contains(p, "/") {
+			target = rel
+			p = strings.TrimPrefix(p, "/")
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(target)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSearched returns true when the solution file `fname` is picked up by
+// searching a directory. Solution filters (*.slnf) are not, because they
+// refer the solutions found anyway, and neither is X.slnx beside X.sln.
+func isSearched(fname string) bool {
+	switch strings.ToLower(filepath.Ext(fname)) {
+	case ".sln":
+		return true
+	case ".slnx":
+		_, err := os.Stat(strings.TrimSuffix(fname, filepath.Ext(fname)) + ".sln")
+		return err != nil
+	}
+	return false
+}
+
+// FindRecursive returns the solution files (*.sln and *.slnx) under `root`.
+// Solution filters (*.slnf) and X.slnx beside X.sln are not returned.
+func FindRecursive(root string, ignores []string) ([]string, error) {
+	result := []string{}
+	err := filepath.WalkDir(root, func(fname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, fname)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if matchIgnore(ignores, filepath.ToSlash(rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && isSearched(fname) {
+			result = append(result, fname)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,77 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFindRecursive(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"top.sln",
+		"src/a/a.sln",
+		"src/a/a.slnf",
+		"src/b/b.slnx",
+		"src/c/c.sln",
+		"src/c/c.slnx",
+		"src/b/bin/copy.sln",
+		"src/old/old.sln",
+		".git/x.sln",
+		"readme.txt",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte{}, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err := FindRecursive(root, append([]string{"src/old/"}, DefaultIgnores...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range found {
+		rel, _ := filepath.Rel(root, f)
+		found[i] = filepath.ToSlash(rel)
+	}
+	if s := strings.Join(found, " "); s != "src/a/a.sln src/b/b.slnx src/c/c.sln top.sln" {
+		t.Fatalf("found=%s", s)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"x.sln", "x.slnx", "x.slnf", "y.slnx", "readme.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	found, err := Find(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(found)
+	if s := strings.Join(found, " "); s != "x.sln y.slnx" {
+		t.Fatalf("found=%s", s)
+	}
+	found, err = Find([]string{"x.slnf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(found, " "); s != "x.slnf" {
+		t.Fatalf("found=%s", s)
+	}
+}
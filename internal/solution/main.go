@@ -1,20 +1,29 @@
 package solution
 
 import (
-	"bufio"
 	"encoding/xml"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/zetamatta/go-numeric-compare"
 )
 
+// IsSolutionFile returns true when `name` has the suffix of solution files.
+func IsSolutionFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".sln", ".slnx", ".slnf":
+		return true
+	}
+	return false
+}
+
 func Find(args []string) ([]string, error) {
 	result := []string{}
 	for _, name := range args {
-		if strings.HasSuffix(strings.ToLower(name), ".sln") {
+		if IsSolutionFile(name) {
 			result = append(result, name)
 		}
 	}
@@ -31,20 +40,149 @@ func Find(args []string) ([]string, error) {
 		return nil, err
 	}
 	for _, file1 := range files {
-		if strings.HasSuffix(strings.ToLower(file1.Name()), ".sln") {
+		if !file1.IsDir() && isSearched(file1.Name()) {
 			result = append(result, file1.Name())
 		}
 	}
 	return result, nil
 }
 
+// Entry is one `Key = Value` line in a ProjectSection or a GlobalSection.
+type Entry struct {
+	Key    string
+	Value  string
+	raw    string
+	blanks []string // blank lines before the entry
+}
+
+// Section is a ProjectSection(...) or GlobalSection(...) block.
+type Section struct {
+	Name    string // ex. "ProjectDependencies", "SolutionConfigurationPlatforms"
+	Timing  string // "preProject", "postProject", "preSolution" or "postSolution"
+	Entries []*Entry
+	raw     string
+	rawEnd  string
+	blanks  []string // blank lines before the end of the section
+}
+
+// Get returns the value of the first entry whose key is `key`.
+func (s *Section) Get(key string) (string, bool) {
+	for _, e := range s.Entries {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// ProjectConfig is the project configuration used when the solution
+// is built with a solution configuration.
+type ProjectConfig struct {
+	ActiveCfg string // ex. "Debug|Win32"
+	Build     bool   // has `.Build.0`
+	Deploy    bool   // has `.Deploy.0`
+}
+
+// Split returns the configuration and the platform of ActiveCfg
+// as the values of $(Configuration) and $(Platform) of the project.
+func (pc *ProjectConfig) Split() (configuration, platform string) {
+	configuration, platform, _ = strings.Cut(pc.ActiveCfg, "|")
+	configuration = strings.TrimSpace(configuration)
+	platform = strings.TrimSpace(platform)
+	if platform == "Any CPU" {
+		platform = "AnyCPU"
+	}
+	return
+}
+
+// Project is a `Project(...) = ...` block of the solution file.
+type Project struct {
+	TypeGUID string
+	Name     string
+	Path     string
+	GUID     string
+	Sections []*Section
+	// Configs maps the solution configurations to the project ones.
+	Configs map[string]*ProjectConfig
+	raw     string
+	rawEnd  string
+	extra   []string // unknown lines in the block
+	trailer []string // unknown lines after the block
+}
+
+// IsFolder returns true when the project is a solution folder.
+func (p *Project) IsFolder() bool {
+	return strings.EqualFold(p.TypeGUID, SolutionFolderGUID)
+}
+
+// Section returns the ProjectSection named `name` or nil.
+func (p *Project) Section(name string) *Section {
+	return findSection(p.Sections, name)
+}
+
 type Solution struct {
-	Path           string
+	Path string
+	// Filter is the path of the solution filter (*.slnf) when the solution
+	// is loaded through it. Then Path is the path of the solution itself.
+	Filter         string
 	MinimumVersion string
 	DefaultVersion string
 	CommentVersion string
 	Configuration  []string
+	// Project maps the relative path of each project to its GUID.
+	// Solution folders are not included.
 	Project        map[string]string
+	Projects       []*Project
+	GlobalSections []*Section
+
+	// the followings are kept to write the solution as it was read.
+	bom          bool
+	newline      string
+	noEOL        bool
+	header       []string
+	footer       []string
+	rawGlobal    string
+	rawEndGlobal string
+	globalExtra  []string
+}
+
+// FilePath returns the path of the file which the solution was loaded from:
+// the solution filter if exists, or the solution.
+func (s *Solution) FilePath() string {
+	if s.Filter != "" {
+		return s.Filter
+	}
+	return s.Path
+}
+
+// ProjectPath returns the path of the project file `proj`
+// to open it from the current directory.
+func (s *Solution) ProjectPath(proj *Project) string {
+	return filepath.Join(filepath.Dir(s.Path), filepath.FromSlash(toSlash(proj.Path)))
+}
+
+// GlobalSection returns the GlobalSection named `name` or nil.
+func (s *Solution) GlobalSection(name string) *Section {
+	return findSection(s.GlobalSections, name)
+}
+
+// FindProject returns the project whose GUID is `guid` or nil.
+func (s *Solution) FindProject(guid string) *Project {
+	for _, p := range s.Projects {
+		if strings.EqualFold(p.GUID, guid) {
+			return p
+		}
+	}
+	return nil
+}
+
+func findSection(sections []*Section, name string) *Section {
+	for _, s := range sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
 }
 
 func (s *Solution) GetMinimumVersion() string {
@@ -80,24 +218,87 @@ var rxDefaultVersion = regexp.MustCompile(`^VisualStudioVersion\s*=\s*(\d+\.\d+)
 var rxMinimumVersion = regexp.MustCompile(`^MinimumVisualStudioVersion\s*=\s*(\d+\.\d+)`)
 
 var rxProjectList = regexp.MustCompile(
-	`^Project\([^)]+\)` +
+	`^Project\("([^"]*)"\)` +
 		`\s*=\s*` +
-		`"[^"]+"\s*,\s*` +
+		`"([^"]*)"\s*,\s*` +
 		`"([^"]+)"\s*,\s*` +
 		`"([^"]+)"`)
 
+var rxSection = regexp.MustCompile(
+	`^\s*(?:Project|Global)Section\(([^)]*)\)\s*=\s*(\S*)`)
+
+func parseEntry(line string) *Entry {
+	key, value, _ := strings.Cut(line, "=")
+	return &Entry{
+		Key:   strings.TrimSpace(key),
+		Value: strings.TrimSpace(value),
+		raw:   line,
+	}
+}
+
+// readSection returns the block function reading the lines of a section
+// until `endMark`, which restores `*block` to `save` at the end.
+func readSection(section *Section, endMark string, block *func(string, []string), save func(string, []string)) func(string, []string) {
+	return func(line string, f []string) {
+		if len(f) > 0 && f[0] == endMark {
+			section.rawEnd = line
+			*block = save
+		} else if strings.TrimSpace(line) == "" {
+			section.blanks = append(section.blanks, line)
+		} else {
+			e := parseEntry(line)
+			e.blanks = section.blanks
+			section.blanks = nil
+			section.Entries = append(section.Entries, e)
+		}
+	}
+}
+
+const bom = "\ufeff"
+
+// splitLines splits the contents of the solution file into lines
+// and records the byte order mark and the style of the line endings.
+func (sln *Solution) splitLines(bin []byte) []string {
+	text := string(bin)
+	if strings.HasPrefix(text, bom) {
+		sln.bom = true
+		text = text[len(bom):]
+	}
+	if strings.Contains(text, "\r\n") {
+		sln.newline = "\r\n"
+	} else {
+		sln.newline = "\n"
+	}
+	lines := strings.Split(text, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		sln.noEOL = true
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
 func New(fname string) (*Solution, error) {
-	fd, err := os.Open(fname)
+	switch strings.ToLower(filepath.Ext(fname)) {
+	case ".slnx":
+		return newSlnx(fname)
+	case ".slnf":
+		return newSlnf(fname)
+	}
+	bin, err := os.ReadFile(fname)
 	if err != nil {
 		return nil, err
 	}
-	defer fd.Close()
 
 	sln := &Solution{
 		Path:    fname,
 		Project: make(map[string]string),
 	}
 
+	endGlobal := false
 	var block func(string, []string)
 	block = func(line string, f []string) {
 		if m := rxCommentVersion.FindStringSubmatch(line); m != nil {
@@ -106,36 +307,140 @@ func New(fname string) (*Solution, error) {
 			sln.DefaultVersion = internalVersionToProductVersion[m[1]]
 		} else if m := rxMinimumVersion.FindStringSubmatch(line); m != nil {
 			sln.MinimumVersion = internalVersionToProductVersion[m[1]]
-		} else if m := rxProjectList.FindStringSubmatch(line); m != nil {
-			sln.Project[m[1]] = m[2]
+		}
+		if m := rxProjectList.FindStringSubmatch(line); m != nil {
+			proj := &Project{
+				TypeGUID: m[1],
+				Name:     m[2],
+				Path:     m[3],
+				GUID:     m[4],
+				raw:      line,
+			}
+			sln.Projects = append(sln.Projects, proj)
 			save := block
-			block = func(line string, f []string) {
-				if f[0] == "EndProject" {
+			var inProject func(string, []string)
+			inProject = func(line string, f []string) {
+				if len(f) > 0 && f[0] == "EndProject" {
+					proj.rawEnd = line
 					block = save
+				} else if m := rxSection.FindStringSubmatch(line); m != nil {
+					section := &Section{Name: m[1], Timing: m[2], raw: line}
+					proj.Sections = append(proj.Sections, section)
+					block = readSection(section, "EndProjectSection", &block, inProject)
+				} else {
+					proj.extra = append(proj.extra, line)
 				}
 			}
-		} else if f[0] == "GlobalSection(SolutionConfigurationPlatforms)" {
+			block = inProject
+		} else if len(f) > 0 && f[0] == "Global" {
+			sln.rawGlobal = line
 			save := block
-			block = func(line string, f []string) {
-				if f[0] == "EndGlobalSection" {
+			var inGlobal func(string, []string)
+			inGlobal = func(line string, f []string) {
+				if len(f) > 0 && f[0] == "EndGlobal" {
+					sln.rawEndGlobal = line
+					endGlobal = true
 					block = save
+				} else if m := rxSection.FindStringSubmatch(line); m != nil {
+					section := &Section{Name: m[1], Timing: m[2], raw: line}
+					sln.GlobalSections = append(sln.GlobalSections, section)
+					block = readSection(section, "EndGlobalSection", &block, inGlobal)
 				} else {
-					piece := strings.Split(line, "=")
-					sln.Configuration = append(sln.Configuration,
-						strings.TrimSpace(piece[0]))
+					sln.globalExtra = append(sln.globalExtra, line)
 				}
 			}
+			block = inGlobal
+		} else if endGlobal {
+			sln.footer = append(sln.footer, line)
+		} else if n := len(sln.Projects); n > 0 {
+			sln.Projects[n-1].trailer = append(sln.Projects[n-1].trailer, line)
+		} else {
+			sln.header = append(sln.header, line)
 		}
 	}
 
-	sc := bufio.NewScanner(fd)
-	for sc.Scan() {
-		text := sc.Text()
+	for _, text := range sln.splitLines(bin) {
 		block(text, strings.Fields(text))
 	}
+	sln.index()
 	return sln, nil
 }
 
+// index rebuilds Project, Configuration and Project.Configs
+// from Projects and GlobalSections.
+func (sln *Solution) index() {
+	sln.Project = make(map[string]string)
+	for _, proj := range sln.Projects {
+		if !proj.IsFolder() {
+			sln.Project[proj.Path] = proj.GUID
+		}
+	}
+	sln.Configuration = nil
+	if section := sln.GlobalSection("SolutionConfigurationPlatforms"); section != nil {
+		for _, e := range section.Entries {
+			sln.Configuration = append(sln.Configuration, e.Key)
+		}
+	}
+	sln.indexProjectConfigs()
+}
+
+// indexProjectConfigs builds Project.Configs from
+// GlobalSection(ProjectConfigurationPlatforms)
+func (sln *Solution) indexProjectConfigs() {
+	for _, proj := range sln.Projects {
+		proj.Configs = make(map[string]*ProjectConfig)
+	}
+	section := sln.GlobalSection("ProjectConfigurationPlatforms")
+	if section == nil {
+		return
+	}
+	for _, e := range section.Entries {
+		// {GUID}.Debug|x86.ActiveCfg = Debug|Win32
+		guid, rest, ok := strings.Cut(e.Key, ".")
+		if !ok {
+			continue
+		}
+		proj := sln.FindProject(guid)
+		if proj == nil {
+			continue
+		}
+		if config := strings.TrimSuffix(rest, ".ActiveCfg"); config != rest {
+			proj.config(config).ActiveCfg = e.Value
+		} else if config := strings.TrimSuffix(rest, ".Build.0"); config != rest {
+			proj.config(config).Build = true
+		} else if config := strings.TrimSuffix(rest, ".Deploy.0"); config != rest {
+			proj.config(config).Deploy = true
+		}
+	}
+}
+
+// IsBuilt returns true when the project is built with the solution
+// configuration `slnConfig`. When the solution has no
+// GlobalSection(ProjectConfigurationPlatforms), every project is built.
+func (sln *Solution) IsBuilt(proj *Project, slnConfig string) bool {
+	if sln.GlobalSection("ProjectConfigurationPlatforms") == nil {
+		return true
+	}
+	pc, ok := proj.Configs[slnConfig]
+	return ok && pc.Build
+}
+
+// IsDeployed returns true when the project is deployed with the solution
+// configuration `slnConfig`.
+func (sln *Solution) IsDeployed(proj *Project, slnConfig string) bool {
+	pc, ok := proj.Configs[slnConfig]
+	return ok && pc.Deploy
+}
+
+func (p *Project) config(slnConfig string) *ProjectConfig {
+	pc, ok := p.Configs[slnConfig]
+	if !ok {
+		pc = &ProjectConfig{}
+		p.Configs[slnConfig] = pc
+	}
+	return pc
+}
+
 type xmlProjectT struct {
 	XMLName         xml.Name `xml:"Project"`
 	ToolsVersion    string   `xml:"ToolsVersion,attr"`
@@ -0,0 +1,242 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleSolution = "\ufeff\r\n" +
+	"Microsoft Visual Studio Solution File, Format Version 12.00\r\n" +
+	"# Visual Studio 15\r\n" +
+	"VisualStudioVersion = 16.0.30114.105\r\n" +
+	"MinimumVisualStudioVersion = 10.0.40219.1\r\n" +
+	"Project(\"{8BC9CEB8-8B4A-11D0-8D11-00A0C91BC942}\") = \"App\", \"App\\App.vcxproj\", \"{11111111-1111-1111-1111-111111111111}\"\r\n" +
+	"\tProjectSection(ProjectDependencies) = postProject\r\n" +
+	"\t\t{22222222-2222-2222-2222-222222222222} = {22222222-2222-2222-2222-222222222222}\r\n" +
+	"\tEndProjectSection\r\n" +
+	"EndProject\r\n" +
+	"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Lib\", \"Lib\\Lib.csproj\", \"{22222222-2222-2222-2222-222222222222}\"\r\n" +
+	"EndProject\r\n" +
+	"Global\r\n" +
+	"\tGlobalSection(SolutionConfigurationPlatforms) = preSolution\r\n" +
+	"\t\tDebug|x86 = Debug|x86\r\n" +
+	"\t\tRelease|x86 = Release|x86\r\n" +
+	"\tEndGlobalSection\r\n" +
+	"\tGlobalSection(ProjectConfigurationPlatforms) = postSolution\r\n" +
+	"\t\t{11111111-1111-1111-1111-111111111111}.Debug|x86.ActiveCfg = Debug|Win32\r\n" +
+	"\t\t{11111111-1111-1111-1111-111111111111}.Debug|x86.Build.0 = Debug|Win32\r\n" +
+	"\t\t{11111111-1111-1111-1111-111111111111}.Release|x86.ActiveCfg = Release|Win32\r\n" +
+	"\t\t{11111111-1111-1111-1111-111111111111}.Release|x86.Build.0 = Release|Win32\r\n" +
+	"\t\t{22222222-2222-2222-2222-222222222222}.Debug|x86.ActiveCfg = Debug|Any CPU\r\n" +
+	"\t\t{22222222-2222-2222-2222-222222222222}.Debug|x86.Build.0 = Debug|Any CPU\r\n" +
+	"\t\t{22222222-2222-2222-2222-222222222222}.Release|x86.ActiveCfg = Debug|Any CPU\r\n" +
+	"\tEndGlobalSection\r\n" +
+	"\tGlobalSection(SolutionProperties) = preSolution\r\n" +
+	"\t\tHideSolutionNode = FALSE\r\n" +
+	"\tEndGlobalSection\r\n" +
+	"EndGlobal\r\n"
+
+func writeSample(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNew(t *testing.T) {
+	sln, err := New(writeSample(t, "sample.sln", sampleSolution))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sln.CommentVersion != "15" {
+		t.Fatalf("CommentVersion=%s", sln.CommentVersion)
+	}
+	if strings.Join(sln.Configuration, ",") != "Debug|x86,Release|x86" {
+		t.Fatalf("Configuration=%v", sln.Configuration)
+	}
+	if len(sln.Projects) != 2 {
+		t.Fatalf("len(Projects)=%d", len(sln.Projects))
+	}
+	app := sln.Projects[0]
+	if app.Name != "App" || app.Path != `App\App.vcxproj` ||
+		app.GUID != "{11111111-1111-1111-1111-111111111111}" ||
+		app.TypeGUID != "{8BC9CEB8-8B4A-11D0-8D11-00A0C91BC942}" {
+		t.Fatalf("Projects[0]=%#v", app)
+	}
+	deps := app.Section("ProjectDependencies")
+	if deps == nil || deps.Timing != "postProject" || len(deps.Entries) != 1 {
+		t.Fatalf("ProjectDependencies=%#v", deps)
+	}
+	if sln.FindProject("{22222222-2222-2222-2222-222222222222}") != sln.Projects[1] {
+		t.Fatal("FindProject failed")
+	}
+	if sln.Project[`Lib\Lib.csproj`] != "{22222222-2222-2222-2222-222222222222}" {
+		t.Fatalf("Project=%v", sln.Project)
+	}
+	if len(sln.GlobalSections) != 3 {
+		t.Fatalf("len(GlobalSections)=%d", len(sln.GlobalSections))
+	}
+	if v, ok := sln.GlobalSection("SolutionProperties").Get("HideSolutionNode"); !ok || v != "FALSE" {
+		t.Fatalf("HideSolutionNode=%s", v)
+	}
+}
+
+func TestProjectConfigs(t *testing.T) {
+	sln, err := New(writeSample(t, "sample.sln", sampleSolution))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := sln.Projects[1]
+	pc, ok := lib.Configs["Release|x86"]
+	if !ok {
+		t.Fatal("Release|x86 not found")
+	}
+	if c, p := pc.Split(); c != "Debug" || p != "AnyCPU" {
+		t.Fatalf("Release|x86 -> %s|%s", c, p)
+	}
+	if c, p := sln.Projects[0].Configs["Debug|x86"].Split(); c != "Debug" || p != "Win32" {
+		t.Fatalf("Debug|x86 -> %s|%s", c, p)
+	}
+	if !sln.IsBuilt(lib, "Debug|x86") {
+		t.Fatal("Lib should be built in Debug|x86")
+	}
+	if sln.IsBuilt(lib, "Release|x86") {
+		t.Fatal("Lib should not be built in Release|x86")
+	}
+	if sln.IsDeployed(lib, "Debug|x86") {
+		t.Fatal("Lib should not be deployed in Debug|x86")
+	}
+}
+
+const sampleSlnx = `<Solution>
+  <Configurations>
+    <BuildType Name="Debug" />
+    <BuildType Name="Release" />
+    <Platform Name="x86" />
+    <Platform Name="x64" />
+  </Configurations>
+  <Folder Name="/src/">
+    <Project Path="src/App/App.vcxproj">
+      <BuildDependency Project="src/Lib/Lib.csproj" />
+    </Project>
+  </Folder>
+  <Folder Name="/src/lib/">
+    <Project Path="src/Lib/Lib.csproj" Type="Classic C#">
+      <BuildType Solution="Release|*" Project="Debug" />
+      <Build Solution="*|x64" Project="false" />
+    </Project>
+  </Folder>
+</Solution>`
+
+func TestSlnx(t *testing.T) {
+	sln, err := New(writeSample(t, "sample.slnx", sampleSlnx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(sln.Configuration, ",") != "Debug|x86,Debug|x64,Release|x86,Release|x64" {
+		t.Fatalf("Configuration=%v", sln.Configuration)
+	}
+	var app, lib *Project
+	for _, p := range sln.Projects {
+		switch p.Name {
+		case "App":
+			app = p
+		case "Lib":
+			lib = p
+		}
+	}
+	if app == nil || lib == nil {
+		t.Fatalf("Projects=%v", sln.Project)
+	}
+	if app.Path != `src\App\App.vcxproj` || app.TypeGUID != CppGUID {
+		t.Fatalf("App=%#v", app)
+	}
+	if lib.TypeGUID != CSharpGUID {
+		t.Fatalf("Lib.TypeGUID=%s", lib.TypeGUID)
+	}
+	if pc := app.Configs["Debug|x86"]; pc == nil || pc.ActiveCfg != "Debug|Win32" || !pc.Build {
+		t.Fatalf("App Debug|x86=%#v", pc)
+	}
+	if pc := lib.Configs["Release|x64"]; pc == nil || pc.ActiveCfg != "Debug|x64" || pc.Build {
+		t.Fatalf("Lib Release|x64=%#v", pc)
+	}
+	deps := app.Section("ProjectDependencies")
+	if deps == nil || len(deps.Entries) != 1 || deps.Entries[0].Key != lib.GUID {
+		t.Fatalf("ProjectDependencies=%#v", deps)
+	}
+	nested := sln.GlobalSection("NestedProjects")
+	if nested == nil {
+		t.Fatal("NestedProjects not found")
+	}
+	if parent, ok := nested.Get(lib.GUID); !ok || sln.FindProject(parent).Name != "lib" {
+		t.Fatalf("parent of Lib=%s", parent)
+	}
+	if len(sln.Project) != 2 {
+		t.Fatalf("folders in Project: %v", sln.Project)
+	}
+	top := sln.Children("")
+	if len(top) != 1 || !top[0].IsFolder() || top[0].Name != "src" {
+		t.Fatalf("top level=%v", top)
+	}
+	if src := sln.Children(top[0].GUID); len(src) != 2 || src[0] != app || src[1].Name != "lib" {
+		t.Fatalf("children of src=%v", src)
+	}
+}
+
+func TestSlnf(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sample.sln"), []byte(sampleSolution), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "filters"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	slnf := filepath.Join(dir, "filters", "lib.slnf")
+	filter := `{
+  "solution": {
+    "path": "..\\sample.sln",
+    "projects": [ "lib\\lib.csproj" ]
+  }
+}`
+	if err := os.WriteFile(slnf, []byte(filter), 0666); err != nil {
+		t.Fatal(err)
+	}
+	sln, err := New(slnf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sln.FilePath() != slnf || sln.Path != filepath.Join(dir, "sample.sln") {
+		t.Fatalf("FilePath()=%s Path=%s", sln.FilePath(), sln.Path)
+	}
+	if len(sln.Projects) != 1 || sln.Projects[0].Name != "Lib" {
+		t.Fatalf("Projects=%v", sln.Project)
+	}
+	if _, ok := sln.Project[`Lib\Lib.csproj`]; !ok || len(sln.Project) != 1 {
+		t.Fatalf("Project=%v", sln.Project)
+	}
+	// the entries of the filtered out projects are removed
+	for _, e := range sln.GlobalSection("ProjectConfigurationPlatforms").Entries {
+		if !strings.HasPrefix(e.Key, "{22222222-") {
+			t.Fatalf("ProjectConfigurationPlatforms: %s remains", e.Key)
+		}
+	}
+
+	filter = strings.Replace(filter, "lib\\\\lib.csproj", "App\\\\App.vcxproj", 1)
+	if err := os.WriteFile(slnf, []byte(filter), 0666); err != nil {
+		t.Fatal(err)
+	}
+	sln, err = New(slnf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sln.Projects) != 1 || sln.Projects[0].Name != "App" {
+		t.Fatalf("Projects=%v", sln.Project)
+	}
+	if deps := sln.Projects[0].Section("ProjectDependencies"); deps != nil {
+		t.Fatalf("ProjectDependencies=%v", deps.Entries)
+	}
+}
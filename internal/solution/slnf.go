@@ -0,0 +1,51 @@
+package solution
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type slnfFilter struct {
+	Solution struct {
+		Path     string   `json:"path"`
+		Projects []string `json:"projects"`
+	} `json:"solution"`
+}
+
+// newSlnf loads the solution referred by the solution filter (*.slnf)
+// and removes the projects not listed in the filter with their entries
+// in the sections, so the filtered solution is consistent by itself.
+func newSlnf(fname string) (*Solution, error) {
+	bin, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var filter slnfFilter
+	if err := json.Unmarshal(bin, &filter); err != nil {
+		return nil, err
+	}
+	slnPath := filepath.Join(filepath.Dir(fname),
+		filepath.FromSlash(toSlash(filter.Solution.Path)))
+	sln, err := New(slnPath)
+	if err != nil {
+		return nil, err
+	}
+	sln.Filter = fname
+
+	listed := make(map[string]struct{}, len(filter.Solution.Projects))
+	for _, p := range filter.Solution.Projects {
+		listed[strings.ToLower(toSlash(p))] = struct{}{}
+	}
+	var excluded []*Project
+	for _, p := range sln.Projects {
+		if _, ok := listed[strings.ToLower(toSlash(p.Path))]; !ok && !p.IsFolder() {
+			excluded = append(excluded, p)
+		}
+	}
+	for _, p := range excluded {
+		sln.RemoveProject(p)
+	}
+	return sln, nil
+}
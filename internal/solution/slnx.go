@@ -0,0 +1,260 @@
+package solution
+
+import (
+	"encoding/xml"
+	"os"
+	"path"
+	"strings"
+)
+
+// The XML solution format (*.slnx) does not have GUIDs nor sections.
+// newSlnx converts it into sections of the same form as *.sln,
+// so that the rest of this package works without knowing the format.
+
+type slnxName struct {
+	Name string `xml:"Name,attr"`
+}
+
+type slnxRule struct {
+	Solution string `xml:"Solution,attr"`
+	Project  string `xml:"Project,attr"`
+}
+
+type slnxProject struct {
+	Path       string     `xml:"Path,attr"`
+	Type       string     `xml:"Type,attr"`
+	ID         string     `xml:"Id,attr"`
+	BuildTypes []slnxRule `xml:"BuildType"`
+	Platforms  []slnxRule `xml:"Platform"`
+	Builds     []slnxRule `xml:"Build"`
+	Deploys    []slnxRule `xml:"Deploy"`
+	Depends    []struct {
+		Project string `xml:"Project,attr"`
+	} `xml:"BuildDependency"`
+}
+
+type slnxFolder struct {
+	Name     string         `xml:"Name,attr"`
+	ID       string         `xml:"Id,attr"`
+	Projects []*slnxProject `xml:"Project"`
+	Files    []struct {
+		Path string `xml:"Path,attr"`
+	} `xml:"File"`
+}
+
+type slnxSolution struct {
+	XMLName    xml.Name       `xml:"Solution"`
+	BuildTypes []slnxName     `xml:"Configurations>BuildType"`
+	Platforms  []slnxName     `xml:"Configurations>Platform"`
+	Folders    []*slnxFolder  `xml:"Folder"`
+	Projects   []*slnxProject `xml:"Project"`
+}
+
+var slnxTypeToGUID = map[string]string{
+	"classic c#": CSharpGUID,
+	"c#":         CSharpSdkGUID,
+	"classic vb": VBGUID,
+	"vb":         VBSdkGUID,
+	"classic f#": FSharpGUID,
+	"f#":         FSharpSdkGUID,
+	"c++":        CppGUID,
+	"vc":         CppGUID,
+	"folder":     SolutionFolderGUID,
+}
+
+func slnxTypeGUID(p *slnxProject) string {
+	if p.Type == "" {
+		return TypeGUIDOf(p.Path)
+	}
+	if strings.HasPrefix(p.Type, "{") {
+		return strings.ToUpper(p.Type)
+	}
+	if guid, ok := slnxTypeToGUID[strings.ToLower(p.Type)]; ok {
+		return guid
+	}
+	return "{" + strings.ToUpper(p.Type) + "}"
+}
+
+func slnxGUID(id, seed string) string {
+	if id == "" {
+		return stableGUID(seed)
+	}
+	return "{" + strings.ToUpper(strings.Trim(id, "{}")) + "}"
+}
+
+// matchPattern tests `config` ("Debug|x64") with `pattern` ("Debug|*")
+func matchPattern(pattern, config string) bool {
+	if pattern == "" {
+		return true
+	}
+	pb, pp, _ := strings.Cut(pattern, "|")
+	cb, cp, _ := strings.Cut(config, "|")
+	if pp == "" {
+		pp = "*"
+	}
+	return (pb == "*" || strings.EqualFold(pb, cb)) &&
+		(pp == "*" || strings.EqualFold(pp, cp))
+}
+
+// applyRules returns the value of the last rule matching `config`.
+func applyRules(rules []slnxRule, config, defaultValue string) string {
+	for _, r := range rules {
+		if matchPattern(r.Solution, config) {
+			defaultValue = r.Project
+		}
+	}
+	return defaultValue
+}
+
+func toWindowsPath(s string) string {
+	return strings.ReplaceAll(s, "/", `\`)
+}
+
+func defaultProjectPlatform(projPath, platform string) string {
+	if strings.EqualFold(path.Ext(projPath), ".vcxproj") {
+		if platform == "x86" || platform == "Any CPU" {
+			return "Win32"
+		}
+	}
+	return platform
+}
+
+func newSlnx(fname string) (*Solution, error) {
+	bin, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var doc slnxSolution
+	if err := xml.Unmarshal(bin, &doc); err != nil {
+		return nil, err
+	}
+	sln := &Solution{Path: fname}
+
+	buildTypes := []string{"Debug", "Release"}
+	if len(doc.BuildTypes) > 0 {
+		buildTypes = buildTypes[:0]
+		for _, b := range doc.BuildTypes {
+			buildTypes = append(buildTypes, b.Name)
+		}
+	}
+	platforms := []string{"Any CPU"}
+	if len(doc.Platforms) > 0 {
+		platforms = platforms[:0]
+		for _, p := range doc.Platforms {
+			platforms = append(platforms, p.Name)
+		}
+	}
+	slnConfigs := &Section{Name: "SolutionConfigurationPlatforms", Timing: "preSolution"}
+	for _, b := range buildTypes {
+		for _, p := range platforms {
+			c := b + "|" + p
+			slnConfigs.Entries = append(slnConfigs.Entries, &Entry{Key: c, Value: c})
+		}
+	}
+	projConfigs := &Section{Name: "ProjectConfigurationPlatforms", Timing: "postSolution"}
+	nested := &Section{Name: "NestedProjects", Timing: "preSolution"}
+
+	folderGUID := map[string]string{}
+	var addFolder func(name, id string) string
+	addFolder = func(name, id string) string {
+		// name is like "/src/lib/"
+		if guid, ok := folderGUID[name]; ok {
+			return guid
+		}
+		trimmed := strings.Trim(name, "/")
+		leaf := path.Base(trimmed)
+		guid := slnxGUID(id, "folder:"+name)
+		folderGUID[name] = guid
+		sln.Projects = append(sln.Projects, &Project{
+			TypeGUID: SolutionFolderGUID,
+			Name:     leaf,
+			Path:     leaf,
+			GUID:     guid,
+		})
+		if parent := path.Dir(trimmed); parent != "." && parent != "/" {
+			parentGUID := addFolder("/"+parent+"/", "")
+			nested.Entries = append(nested.Entries, &Entry{Key: guid, Value: parentGUID})
+		}
+		return guid
+	}
+
+	pathToGUID := map[string]string{}
+	type pending struct {
+		proj *Project
+		xml  *slnxProject
+	}
+	var projects []pending
+	addProject := func(p *slnxProject, parentGUID string) {
+		proj := &Project{
+			TypeGUID: slnxTypeGUID(p),
+			Name:     strings.TrimSuffix(path.Base(toSlash(p.Path)), path.Ext(p.Path)),
+			Path:     toWindowsPath(p.Path),
+			GUID:     slnxGUID(p.ID, "project:"+strings.ToLower(toSlash(p.Path))),
+		}
+		sln.Projects = append(sln.Projects, proj)
+		pathToGUID[strings.ToLower(toSlash(p.Path))] = proj.GUID
+		projects = append(projects, pending{proj: proj, xml: p})
+		if parentGUID != "" {
+			nested.Entries = append(nested.Entries, &Entry{Key: proj.GUID, Value: parentGUID})
+		}
+		for _, e := range slnConfigs.Entries {
+			c := e.Key
+			b, pf, _ := strings.Cut(c, "|")
+			active := applyRules(p.BuildTypes, c, b) + "|" +
+				applyRules(p.Platforms, c, defaultProjectPlatform(p.Path, pf))
+			projConfigs.Entries = append(projConfigs.Entries,
+				&Entry{Key: proj.GUID + "." + c + ".ActiveCfg", Value: active})
+			if !strings.EqualFold(applyRules(p.Builds, c, "true"), "false") {
+				projConfigs.Entries = append(projConfigs.Entries,
+					&Entry{Key: proj.GUID + "." + c + ".Build.0", Value: active})
+			}
+			if !strings.EqualFold(applyRules(p.Deploys, c, "false"), "false") {
+				projConfigs.Entries = append(projConfigs.Entries,
+					&Entry{Key: proj.GUID + "." + c + ".Deploy.0", Value: active})
+			}
+		}
+	}
+
+	for _, p := range doc.Projects {
+		addProject(p, "")
+	}
+	for _, f := range doc.Folders {
+		guid := addFolder(f.Name, f.ID)
+		if len(f.Files) > 0 {
+			folder := sln.FindProject(guid)
+			items := &Section{Name: "SolutionItems", Timing: "preProject"}
+			for _, file := range f.Files {
+				p := toWindowsPath(file.Path)
+				items.Entries = append(items.Entries, &Entry{Key: p, Value: p})
+			}
+			folder.Sections = append(folder.Sections, items)
+		}
+		for _, p := range f.Projects {
+			addProject(p, guid)
+		}
+	}
+
+	for _, p := range projects {
+		if len(p.xml.Depends) <= 0 {
+			continue
+		}
+		deps := &Section{Name: "ProjectDependencies", Timing: "postProject"}
+		for _, d := range p.xml.Depends {
+			if guid, ok := pathToGUID[strings.ToLower(toSlash(d.Project))]; ok {
+				deps.Entries = append(deps.Entries, &Entry{Key: guid, Value: guid})
+			}
+		}
+		p.proj.Sections = append(p.proj.Sections, deps)
+	}
+
+	sln.GlobalSections = append(sln.GlobalSections, slnConfigs, projConfigs)
+	if len(nested.Entries) > 0 {
+		sln.GlobalSections = append(sln.GlobalSections, nested)
+	}
+	sln.index()
+	return sln, nil
+}
+
+func toSlash(s string) string {
+	return strings.ReplaceAll(s, `\`, "/")
+}
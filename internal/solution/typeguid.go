@@ -0,0 +1,70 @@
+package solution
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Project type GUIDs written in `Project("{...}")`
+const (
+	SolutionFolderGUID = "{2150E333-8FDC-42A3-9474-1A3956D46DE8}"
+	CSharpGUID         = "{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}"
+	CSharpSdkGUID      = "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}"
+	VBGUID             = "{F184B08F-C81C-45F6-A57F-5ABD9991F28F}"
+	VBSdkGUID          = "{778DAE3C-4631-46EA-AA77-85C1314464D9}"
+	FSharpGUID         = "{F2A71F9B-5D33-465A-A702-920D77279786}"
+	FSharpSdkGUID      = "{6EC3EE1D-3C4E-46DD-8F32-0CC8E7565705}"
+	CppGUID            = "{8BC9CEB8-8B4A-11D0-8D11-00A0C91BC942}"
+)
+
+var extToTypeGUID = map[string]string{
+	".csproj":  CSharpGUID,
+	".vbproj":  VBGUID,
+	".fsproj":  FSharpGUID,
+	".vcxproj": CppGUID,
+}
+
+var typeGUIDToName = map[string]string{
+	SolutionFolderGUID: "Folder",
+	CSharpGUID:         "C#",
+	CSharpSdkGUID:      "C#",
+	VBGUID:             "VB",
+	VBSdkGUID:          "VB",
+	FSharpGUID:         "F#",
+	FSharpSdkGUID:      "F#",
+	CppGUID:            "C++",
+}
+
+// TypeName returns the short name of the project type GUID.
+func TypeName(typeGUID string) string {
+	if name, ok := typeGUIDToName[strings.ToUpper(typeGUID)]; ok {
+		return name
+	}
+	return typeGUID
+}
+
+// TypeGUIDOf returns the project type GUID for the project file `path`
+// or an empty string for unknown types.
+func TypeGUIDOf(path string) string {
+	return extToTypeGUID[strings.ToLower(filepath.Ext(path))]
+}
+
+// stableGUID makes a GUID from `seed` that is always same for same seed.
+func stableGUID(seed string) string {
+	h := md5.Sum([]byte(seed))
+	return fmt.Sprintf("{%X-%X-%X-%X-%X}", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// newGUID makes a random GUID (version 4)
+func newGUID() string {
+	var h [16]byte
+	if _, err := rand.Read(h[:]); err != nil {
+		panic(err)
+	}
+	h[6] = (h[6] & 0x0F) | 0x40
+	h[8] = (h[8] & 0x3F) | 0x80
+	return fmt.Sprintf("{%X-%X-%X-%X-%X}", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
@@ -0,0 +1,137 @@
+package solution
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var defaultHeader = []string{
+	"",
+	"Microsoft Visual Studio Solution File, Format Version 12.00",
+}
+
+func (e *Entry) String() string {
+	if e.raw != "" {
+		if org := parseEntry(e.raw); org.Key == e.Key && org.Value == e.Value {
+			return e.raw
+		}
+	}
+	return "\t\t" + e.Key + " = " + e.Value
+}
+
+func (s *Section) lines(kind string) []string {
+	header := fmt.Sprintf("\t%sSection(%s) = %s", kind, s.Name, s.Timing)
+	if s.raw != "" {
+		if m := rxSection.FindStringSubmatch(s.raw); m != nil && m[1] == s.Name && m[2] == s.Timing {
+			header = s.raw
+		}
+	}
+	lines := []string{header}
+	for _, e := range s.Entries {
+		lines = append(lines, e.blanks...)
+		lines = append(lines, e.String())
+	}
+	lines = append(lines, s.blanks...)
+	if s.rawEnd != "" {
+		return append(lines, s.rawEnd)
+	}
+	return append(lines, "\tEnd"+kind+"Section")
+}
+
+func (p *Project) lines() []string {
+	header := fmt.Sprintf("Project(\"%s\") = \"%s\", \"%s\", \"%s\"",
+		p.TypeGUID, p.Name, p.Path, p.GUID)
+	if p.raw != "" {
+		if m := rxProjectList.FindStringSubmatch(p.raw); m != nil &&
+			m[1] == p.TypeGUID && m[2] == p.Name && m[3] == p.Path && m[4] == p.GUID {
+			header = p.raw
+		}
+	}
+	lines := []string{header}
+	for _, s := range p.Sections {
+		lines = append(lines, s.lines("Project")...)
+	}
+	lines = append(lines, p.extra...)
+	if p.rawEnd != "" {
+		lines = append(lines, p.rawEnd)
+	} else {
+		lines = append(lines, "EndProject")
+	}
+	return append(lines, p.trailer...)
+}
+
+func (sln *Solution) lines() []string {
+	lines := sln.header
+	if lines == nil {
+		lines = defaultHeader
+	}
+	lines = append([]string{}, lines...)
+	for _, p := range sln.Projects {
+		lines = append(lines, p.lines()...)
+	}
+	if sln.rawGlobal != "" {
+		lines = append(lines, sln.rawGlobal)
+	} else {
+		lines = append(lines, "Global")
+	}
+	for _, s := range sln.GlobalSections {
+		lines = append(lines, s.lines("Global")...)
+	}
+	lines = append(lines, sln.globalExtra...)
+	if sln.rawEndGlobal != "" {
+		lines = append(lines, sln.rawEndGlobal)
+	} else {
+		lines = append(lines, "EndGlobal")
+	}
+	return append(lines, sln.footer...)
+}
+
+// WriteTo writes the solution in the format of *.sln.
+// An unmodified solution is written as same bytes as the file read.
+func (sln *Solution) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	if sln.bom || sln.newline == "" {
+		m, _ := bw.WriteString(bom)
+		n += int64(m)
+	}
+	newline := sln.newline
+	if newline == "" {
+		newline = "\r\n"
+	}
+	lines := sln.lines()
+	for i, line := range lines {
+		m, _ := bw.WriteString(line)
+		n += int64(m)
+		if i < len(lines)-1 || !sln.noEOL {
+			m, _ = bw.WriteString(newline)
+			n += int64(m)
+		}
+	}
+	return n, bw.Flush()
+}
+
+// Save overwrites the solution file.
+func (sln *Solution) Save() error {
+	if sln.Filter != "" || !strings.EqualFold(filepath.Ext(sln.Path), ".sln") {
+		return fmt.Errorf("%s: %w", sln.FilePath(), ErrNotSupported)
+	}
+	fd, err := os.Create(sln.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := sln.WriteTo(fd); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// ErrNotSupported is returned when the solution can not be written
+// because it was not read from *.sln.
+var ErrNotSupported = errors.New("only *.sln can be written")
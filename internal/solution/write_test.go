@@ -0,0 +1,105 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	samples := []string{
+		sampleSolution,
+		strings.ReplaceAll(sampleSolution[len(bom):], "\r\n", "\n"),
+		strings.TrimSuffix(sampleSolution, "\r\n"),
+		strings.Replace(sampleSolution, "\t\tHideSolutionNode = FALSE", "  HideSolutionNode=FALSE", 1),
+		// blank lines inside a section
+		strings.Replace(sampleSolution, "\t\tHideSolutionNode = FALSE\r\n", "\r\n\t\tHideSolutionNode = FALSE\r\n\t\r\n", 1),
+	}
+	for i, sample := range samples {
+		sln, err := New(writeSample(t, "sample.sln", sample))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buffer strings.Builder
+		if _, err := sln.WriteTo(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != sample {
+			t.Fatalf("sample %d: round trip failed:\n%s", i, buffer.String())
+		}
+	}
+}
+
+const sampleVcxproj = `<?xml version="1.0" encoding="utf-8"?>
+<Project DefaultTargets="Build" ToolsVersion="15.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <ItemGroup Label="ProjectConfigurations">
+    <ProjectConfiguration Include="Debug|Win32"><Configuration>Debug</Configuration><Platform>Win32</Platform></ProjectConfiguration>
+    <ProjectConfiguration Include="Release|Win32"><Configuration>Release</Configuration><Platform>Win32</Platform></ProjectConfiguration>
+  </ItemGroup>
+  <PropertyGroup Label="Globals">
+    <ProjectGuid>{33333333-3333-3333-3333-333333333333}</ProjectGuid>
+  </PropertyGroup>
+</Project>`
+
+func TestAddRemoveProject(t *testing.T) {
+	slnPath := writeSample(t, "sample.sln", sampleSolution)
+	dir := filepath.Dir(slnPath)
+	if err := os.Mkdir(filepath.Join(dir, "Tool"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	projPath := filepath.Join(dir, "Tool", "Tool.vcxproj")
+	if err := os.WriteFile(projPath, []byte(sampleVcxproj), 0666); err != nil {
+		t.Fatal(err)
+	}
+	sln, err := New(slnPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := sln.AddProject(projPath, "tools/native")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.GUID != "{33333333-3333-3333-3333-333333333333}" || proj.Path != `Tool\Tool.vcxproj` {
+		t.Fatalf("added=%#v", proj)
+	}
+	if pc := proj.Configs["Release|x86"]; pc == nil || pc.ActiveCfg != "Release|Win32" || !pc.Build {
+		t.Fatalf("Release|x86=%#v", pc)
+	}
+	native := sln.FindProject(sln.ParentGUID(proj.GUID))
+	if native == nil || native.Name != "native" {
+		t.Fatalf("parent=%#v", native)
+	}
+	if tools := sln.FindProject(sln.ParentGUID(native.GUID)); tools == nil || tools.Name != "tools" {
+		t.Fatalf("grandparent=%#v", tools)
+	}
+	if sln.AddFolder("tools/native") != native {
+		t.Fatal("AddFolder created the existing folder again")
+	}
+	if _, err := sln.AddProject(projPath, ""); err == nil {
+		t.Fatal("a project was registered twice")
+	}
+
+	var buffer strings.Builder
+	sln.WriteTo(&buffer)
+	if !strings.Contains(buffer.String(),
+		"Project(\""+CppGUID+"\") = \"Tool\", \"Tool\\Tool.vcxproj\", \"{33333333-3333-3333-3333-333333333333}\"\r\nEndProject\r\n") {
+		t.Fatalf("added project not found:\n%s", buffer.String())
+	}
+	if !strings.Contains(buffer.String(), "\tGlobalSection(NestedProjects) = preSolution\r\n") {
+		t.Fatalf("NestedProjects not found:\n%s", buffer.String())
+	}
+
+	sln.RemoveProject(proj)
+	sln.RemoveProject(native)
+	sln.RemoveProject(sln.AddFolder("tools"))
+	sln.RemoveProject(sln.FindProject("{22222222-2222-2222-2222-222222222222}"))
+	if len(sln.Projects) != 1 {
+		t.Fatalf("Projects=%v", sln.Project)
+	}
+	buffer.Reset()
+	sln.WriteTo(&buffer)
+	if strings.Contains(buffer.String(), "2222") || strings.Contains(buffer.String(), "3333") {
+		t.Fatalf("removed project remains:\n%s", buffer.String())
+	}
+}